@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/jwt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const keysUsage = `usage: server keys <command>
+
+commands:
+  generate [RS256|EdDSA]  write a new signing key to JWT_KEYS_DIR (default RS256)
+
+Rotating keys:
+  1. generate a key and set JWT_ACTIVE_KID to the current kid, so the new
+     key is published in /.well-known/jwks.json before it signs
+  2. once verifiers have refreshed their key sets, set JWT_ACTIVE_KID to the new kid
+     (or unset it, the newest kid signs by default)
+  3. after JWT_ACCESS_TTL has passed, delete the old key file`
+
+// runKeysCommand handles "server keys ..." and exits the process
+func runKeysCommand(args []string) {
+	if len(args) == 0 || args[0] != "generate" || len(args) > 2 {
+		fmt.Fprintln(os.Stderr, keysUsage)
+		os.Exit(2)
+	}
+
+	algorithm := jwt.AlgorithmRS256
+	if len(args) == 2 {
+		algorithm = args[1]
+	}
+
+	// keys only needs the directory, not the whole config
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		dir = "keys"
+	}
+
+	data, err := jwt.GenerateKey(algorithm)
+	if err != nil {
+		exitWithKeysError(err)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		exitWithKeysError(err)
+	}
+
+	// kids sort by creation time, so the newest key is the default signing key
+	kid, err := newKeyID()
+	if err != nil {
+		exitWithKeysError(err)
+	}
+	path := filepath.Join(dir, kid+".pem")
+	// never overwrite a key that may still be verifying tokens
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		exitWithKeysError(err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		exitWithKeysError(err)
+	}
+	if err := file.Close(); err != nil {
+		exitWithKeysError(err)
+	}
+
+	fmt.Println("Created", path)
+	fmt.Println("kid", kid)
+}
+
+// generateTemporaryKey creates an in-memory signing key for development
+func generateTemporaryKey() (*jwt.Key, error) {
+	data, err := jwt.GenerateKey(jwt.AlgorithmEdDSA)
+	if err != nil {
+		return nil, err
+	}
+	kid, err := newKeyID()
+	if err != nil {
+		return nil, err
+	}
+	return jwt.ParseKeyPEM("temporary-"+kid, data)
+}
+
+// newKeyID returns a kid such as 20261018-143015.123456-9f86d081. The random
+// suffix keeps two keys generated in the same microsecond apart.
+func newKeyID() (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return time.Now().UTC().Format("20060102-150405.000000") + "-" + hex.EncodeToString(suffix), nil
+}
+
+func exitWithKeysError(err error) {
+	fmt.Fprintln(os.Stderr, "keys:", err)
+	os.Exit(1)
+}
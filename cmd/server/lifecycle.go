@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	pkgLogger "github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// closer is a resource released on shutdown
+type closer struct {
+	name  string
+	close func() error
+}
+
+// lifecycle runs the HTTP server and background workers, and on SIGINT or
+// SIGTERM shuts them down in order: stop accepting requests and drain
+// in-flight ones, stop workers, then close resources in reverse order of registration.
+type lifecycle struct {
+	ctx             context.Context
+	cancel          context.CancelFunc
+	workers         sync.WaitGroup
+	closers         []closer
+	shutdownTimeout time.Duration
+}
+
+func newLifecycle(shutdownTimeout time.Duration) *lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &lifecycle{
+		ctx:             ctx,
+		cancel:          cancel,
+		shutdownTimeout: shutdownTimeout,
+	}
+}
+
+// Go starts a background worker, ctx is cancelled when shutdown begins
+func (l *lifecycle) Go(name string, worker func(ctx context.Context)) {
+	l.workers.Add(1)
+	go func() {
+		defer l.workers.Done()
+		worker(l.ctx)
+		pkgLogger.Info("Worker stopped: " + name)
+	}()
+}
+
+// OnShutdown registers a resource to close, the last registered is closed first
+func (l *lifecycle) OnShutdown(name string, close func() error) {
+	l.closers = append(l.closers, closer{name: name, close: close})
+}
+
+// Run serves until a signal arrives or the listener fails, then shuts down
+func (l *lifecycle) Run(app *fiber.App, addr string) error {
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(addr)
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var err error
+	select {
+	case sig := <-signals:
+		pkgLogger.Info("Received " + sig.String() + ", shutting down")
+	case err = <-listenErr:
+		pkgLogger.Error("Server stopped: " + errString(err))
+	}
+
+	l.shutdown(app)
+	return err
+}
+
+// shutdown drains the server, stops workers and closes resources,
+// each step bounded by the shutdown timeout
+func (l *lifecycle) shutdown(app *fiber.App) {
+	if err := app.ShutdownWithTimeout(l.shutdownTimeout); err != nil {
+		pkgLogger.Error("HTTP server shutdown: " + err.Error())
+	}
+	pkgLogger.Info("HTTP server stopped")
+
+	l.cancel()
+	done := make(chan struct{})
+	go func() {
+		l.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(l.shutdownTimeout):
+		pkgLogger.Error("Timed out waiting for background workers")
+	}
+
+	for i := len(l.closers) - 1; i >= 0; i-- {
+		c := l.closers[i]
+		if err := c.close(); err != nil {
+			pkgLogger.Error("Closing " + c.name + ": " + err.Error())
+			continue
+		}
+		pkgLogger.Info("Closed " + c.name)
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return "listener closed"
+	}
+	return err.Error()
+}
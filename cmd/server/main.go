@@ -1,79 +1,383 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/config"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/database"
-	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/middleware"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/routes"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/apperror"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/cache"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/health"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/jwt"
 	pkgLogger "github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/logger"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/mailer"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/metrics"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/ratelimit"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/response"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/revocation"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/tracing"
+	pkgUtils "github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
 	"log"
+	"os"
+	"strings"
 )
 
 func main() {
+	// "server migrate ..." manages the database schema instead of serving
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(os.Args[2:]); err != nil {
+			exitWithMigrateError(err)
+		}
+		return
+	}
+	// "server keys ..." manages the access token signing keys
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		runKeysCommand(os.Args[2:])
+		return
+	}
+
 	// Load configuration
 	cfg := config.LoadConfig()
+	if err := pkgLogger.Init(cfg.App.LogLevel, cfg.App.LogFormat); err != nil {
+		log.Fatal("Logger setup failed:", err)
+	}
+	setupPasswordHasher(cfg)
+
+	// Lifecycle closes everything registered below on shutdown
+	lc := newLifecycle(cfg.App.ShutdownTimeout)
+
+	// Setup tracing first so it is flushed after everything else has closed
+	lc.OnShutdown("tracing", setupTracing(cfg))
 
 	// Setup database
-	setupDatabase(cfg)
+	db := setupDatabase(cfg)
+	lc.OnShutdown("database", func() error { return database.CloseDB(db) })
+
+	// Setup metrics, instrumenting the database
+	appMetrics := setupMetrics(db)
+	if err := db.Use(tracing.NewGormPlugin()); err != nil {
+		log.Fatal("Database tracing setup failed:", err)
+	}
+
+	// Setup Redis backed dependencies
+	redisClient := setupRedis(cfg)
+	if redisClient != nil {
+		lc.OnShutdown("redis", redisClient.Close)
+	}
+	deps := &routes.Dependencies{
+		DB:        db,
+		Cache:     setupCache(cfg, redisClient),
+		RateLimit: setupRateLimitStore(cfg, redisClient),
+		Health:    setupHealthChecks(cfg, db, redisClient),
+		Metrics:   appMetrics,
+		Mailer:    setupMailer(cfg),
+		Keys:      setupKeyRing(cfg),
+		Denylist:  revocation.NewDenylist(setupRevocationStore(cfg, redisClient), cfg.JWT.AccessTTL),
+	}
 
 	// Setup Fiber app
-	app := setupFiberApp()
+	app := setupFiberApp(cfg, appMetrics)
 
 	// Setup routes (handles all dependencies internally)
-	routes.SetupRoutes(app, cfg)
+	routes.SetupRoutes(app, cfg, deps)
+
+	// Start background workers
+	startWorkers(lc, db)
 
-	// Start server
-	startServer(app, cfg.App.Port)
+	// Start server, blocks until shutdown completes
+	startServer(lc, app, cfg.App.Port)
 }
 
-func setupDatabase(cfg *config.Config) {
-	pkgLogger.Init()
+func setupDatabase(cfg *config.Config) *gorm.DB {
 	pkgLogger.Info("Starting Go REST API Boilerplate")
 
-	database.ConnectDB(cfg)
+	db, err := database.ConnectDB(cfg)
+	if err != nil {
+		log.Fatal("Database connection failed:", err)
+	}
 	pkgLogger.Info("Database connected")
 
-	if err := database.DB.AutoMigrate(&models.User{}, &models.Book{}); err != nil {
+	if !cfg.Database.MigrateOnStart {
+		return db
+	}
+	if err := runMigrations(db); err != nil {
 		log.Fatal("Database migration failed:", err)
 	}
 	pkgLogger.Info("Database migration completed")
+
+	return db
+}
+
+// setupRedis connects to Redis when a component is configured to use it
+func setupRedis(cfg *config.Config) *redis.Client {
+	if cfg.Cache.Driver != "redis" && cfg.RateLimit.Driver != "redis" && cfg.Revoke.Driver != "redis" {
+		return nil
+	}
+
+	client, err := database.ConnectRedis(cfg)
+	if err != nil {
+		log.Fatal("Redis connection failed:", err)
+	}
+	pkgLogger.Info("Redis connected")
+
+	return client
+}
+
+// setupCache picks the cache backend from CACHE_DRIVER, nil disables caching
+func setupCache(cfg *config.Config, redisClient *redis.Client) cache.Store {
+	switch cfg.Cache.Driver {
+	case "redis":
+		return cache.NewRedisStore(redisClient)
+	case "memory":
+		return cache.NewMemoryStore()
+	case "none", "":
+		return nil
+	default:
+		log.Fatal("Unknown CACHE_DRIVER: " + cfg.Cache.Driver)
+		return nil
+	}
+}
+
+// setupRateLimitStore picks the rate limit backend from RATE_LIMIT_DRIVER
+func setupRateLimitStore(cfg *config.Config, redisClient *redis.Client) ratelimit.Store {
+	switch cfg.RateLimit.Driver {
+	case "redis":
+		return ratelimit.NewRedisStore(redisClient)
+	case "memory":
+		return ratelimit.NewMemoryStore()
+	default:
+		log.Fatal("Unknown RATE_LIMIT_DRIVER: " + cfg.RateLimit.Driver)
+		return nil
+	}
+}
+
+// setupRevocationStore picks the access token denylist backend from REVOCATION_DRIVER
+func setupRevocationStore(cfg *config.Config, redisClient *redis.Client) revocation.Store {
+	switch cfg.Revoke.Driver {
+	case "redis":
+		return revocation.NewRedisStore(redisClient)
+	case "memory":
+		return revocation.NewMemoryStore()
+	default:
+		log.Fatal("Unknown REVOCATION_DRIVER: " + cfg.Revoke.Driver)
+		return nil
+	}
+}
+
+// setupMailer picks the mail backend from MAIL_DRIVER
+func setupMailer(cfg *config.Config) mailer.Mailer {
+	switch cfg.Mail.Driver {
+	case "smtp":
+		return mailer.NewSMTPMailer(cfg.Mail.SMTPHost, cfg.Mail.SMTPPort, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword, cfg.Mail.From)
+	case "file":
+		fileMailer, err := mailer.NewFileMailer(cfg.Mail.FileDir, cfg.Mail.From)
+		if err != nil {
+			log.Fatal("Mail directory setup failed:", err)
+		}
+		return fileMailer
+	case "log":
+		return mailer.NewLogMailer()
+	default:
+		log.Fatal("Unknown MAIL_DRIVER: " + cfg.Mail.Driver)
+		return nil
+	}
+}
+
+// setupKeyRing loads the access token signing keys from JWT_KEYS_DIR. Outside
+// production an empty directory gets a throwaway key, so tokens stop working on restart.
+func setupKeyRing(cfg *config.Config) *jwt.KeyRing {
+	keys, err := jwt.LoadKeys(cfg.JWT.KeysDir)
+	if err != nil {
+		log.Fatal("Loading JWT keys failed:", err)
+	}
+
+	if len(keys) == 0 {
+		if cfg.App.Env == "production" {
+			log.Fatal("No JWT keys in " + cfg.JWT.KeysDir + ", create one with: server keys generate")
+		}
+		pkgLogger.Log.Warn("No JWT keys in " + cfg.JWT.KeysDir + ", using a temporary key")
+
+		key, err := generateTemporaryKey()
+		if err != nil {
+			log.Fatal("Generating temporary JWT key failed:", err)
+		}
+		keys = append(keys, key)
+	}
+
+	ring, err := jwt.NewKeyRing(keys, cfg.JWT.ActiveKeyID, cfg.JWT.Issuer, cfg.JWT.Audience)
+	if err != nil {
+		log.Fatal("JWT key ring setup failed:", err)
+	}
+	pkgLogger.Info("Signing access tokens with key " + ring.ActiveKeyID())
+
+	return ring
+}
+
+// setupPasswordHasher picks the algorithm for new password hashes from PASSWORD_HASHER
+func setupPasswordHasher(cfg *config.Config) {
+	switch cfg.Password.Hasher {
+	case "argon2id":
+		params := pkgUtils.DefaultArgon2idParams
+		params.Memory = cfg.Password.Argon2Memory
+		params.Iterations = cfg.Password.Argon2Iterations
+		params.Parallelism = cfg.Password.Argon2Parallelism
+		if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+			log.Fatal("Argon2 memory, iterations and parallelism must be positive")
+		}
+		pkgUtils.SetPasswordHasher(pkgUtils.NewArgon2idHasher(params))
+	case "bcrypt":
+		hasher, err := pkgUtils.NewBcryptHasher(cfg.Password.BcryptCost)
+		if err != nil {
+			log.Fatal("Password hashing setup failed:", err)
+		}
+		pkgUtils.SetPasswordHasher(hasher)
+	default:
+		log.Fatal("Unknown PASSWORD_HASHER: " + cfg.Password.Hasher)
+	}
+}
+
+// setupHealthChecks registers the dependency checks behind /health/ready
+func setupHealthChecks(cfg *config.Config, db *gorm.DB, redisClient *redis.Client) *health.Registry {
+	registry := health.NewRegistry(cfg.Health.Timeout)
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal("Database handle unavailable:", err)
+	}
+	registry.Register("postgres", health.SQLCheck(sqlDB), true)
+
+	if redisClient != nil {
+		registry.Register("redis", health.RedisCheck(redisClient), true)
+	}
+
+	registry.Register("disk", health.DiskCheck(cfg.Health.DiskPath, cfg.Health.DiskMinFreeMB*1024*1024), false)
+
+	return registry
+}
+
+// setupTracing installs the span exporter from TRACING_EXPORTER and returns its flush on shutdown
+func setupTracing(cfg *config.Config) func() error {
+	shutdown, err := tracing.Setup(context.Background(), tracing.Options{
+		ServiceName:  cfg.App.Name,
+		Environment:  cfg.App.Env,
+		Exporter:     cfg.Tracing.Exporter,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+		OTLPInsecure: cfg.Tracing.OTLPInsecure,
+		FilePath:     cfg.Tracing.FilePath,
+		SampleRatio:  cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatal("Tracing setup failed:", err)
+	}
+
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.App.ShutdownTimeout)
+		defer cancel()
+		return shutdown(ctx)
+	}
+}
+
+// setupMetrics creates the Prometheus collectors and instruments GORM and the connection pool
+func setupMetrics(db *gorm.DB) *metrics.Metrics {
+	appMetrics := metrics.New()
+
+	if err := db.Use(metrics.NewGormPlugin(appMetrics)); err != nil {
+		log.Fatal("Database metrics setup failed:", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal("Database handle unavailable:", err)
+	}
+	appMetrics.RegisterDBStats(sqlDB, "postgres")
+
+	return appMetrics
 }
 
-func setupFiberApp() *fiber.App {
+func setupFiberApp(cfg *config.Config, appMetrics *metrics.Metrics) *fiber.App {
 	app := fiber.New(fiber.Config{
-		AppName: "Go REST API Boilerplate v1.0.0",
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			pkgLogger.Error("Global error: " + err.Error())
-
-			if e, ok := err.(*fiber.Error); ok {
-				if e.Code == fiber.StatusBadRequest {
-					return response.BadRequest(c, e.Message)
-				}
-				if e.Code == fiber.StatusUnauthorized {
-					return response.Unauthorized(c, e.Message)
-				}
-			}
-
-			return response.InternalError(c, "Internal Server Error")
-		},
+		AppName:      "Go REST API Boilerplate v1.0.0",
+		ErrorHandler: errorHandler,
+		ReadTimeout:  cfg.App.ReadTimeout,
+		WriteTimeout: cfg.App.WriteTimeout,
+		IdleTimeout:  cfg.App.IdleTimeout,
+
+		// only trust the proxy header from the configured load balancers
+		ProxyHeader:             cfg.App.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.App.TrustedProxies,
 	})
 
+	app.Use(middleware.RequestID())
+	app.Use(middleware.Tracing())
+	app.Use(middleware.Metrics(appMetrics))
+	app.Use(middleware.RequestLogger())
+
+	// a panicking handler answers 500 through the error handler instead of taking the process down
+	app.Use(recover.New(recover.Config{EnableStackTrace: true}))
+
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
 		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
 		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
 	}))
 
-	app.Use(logger.New())
-
 	return app
 }
 
-func startServer(app *fiber.App, port string) {
+// errorHandler is the single place where errors returned by handlers are turned into responses
+func errorHandler(c *fiber.Ctx, err error) error {
+	if appErr, ok := apperror.As(err); ok {
+		status := statusForKind(appErr.Kind)
+		if status >= fiber.StatusInternalServerError {
+			pkgLogger.FromContext(c.UserContext()).WithError(err).Error("request failed")
+		}
+		return response.Error(c, status, appErr.Code, appErr.Message, appErr.Details)
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) && fiberErr.Code < fiber.StatusInternalServerError {
+		// e.g. 404 -> "NOT_FOUND"
+		errorCode := strings.ToUpper(strings.ReplaceAll(utils.StatusMessage(fiberErr.Code), " ", "_"))
+		return response.Error(c, fiberErr.Code, errorCode, fiberErr.Message, nil)
+	}
+
+	pkgLogger.FromContext(c.UserContext()).WithError(err).Error("request failed")
+	return response.InternalError(c, "Internal Server Error")
+}
+
+// statusForKind maps domain error kinds to HTTP status codes
+func statusForKind(kind apperror.Kind) int {
+	switch kind {
+	case apperror.KindValidation:
+		return fiber.StatusBadRequest
+	case apperror.KindUnauthorized:
+		return fiber.StatusUnauthorized
+	case apperror.KindForbidden:
+		return fiber.StatusForbidden
+	case apperror.KindNotFound:
+		return fiber.StatusNotFound
+	case apperror.KindConflict:
+		return fiber.StatusConflict
+	case apperror.KindRateLimited:
+		return fiber.StatusTooManyRequests
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+func startServer(lc *lifecycle, app *fiber.App, port string) {
 	pkgLogger.Info("Server starting on port " + port)
-	log.Fatal(app.Listen(":" + port))
+	if err := lc.Run(app, ":"+port); err != nil {
+		log.Fatal(err)
+	}
+	pkgLogger.Info("Server stopped")
 }
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/config"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/database"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/migrations"
+	pkgLogger "github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/logger"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/migrate"
+	"gorm.io/gorm"
+	"os"
+	"strconv"
+)
+
+const migrateUsage = `usage: server migrate <command>
+
+commands:
+  up [N]         apply all or the next N pending migrations
+  down [N]       roll back the last N applied migrations (default 1)
+  status         list migrations and whether they are applied
+  create <name>  create a new numbered up/down pair in MIGRATIONS_DIR`
+
+// errMigrateUsage reports a malformed "server migrate" command line
+var errMigrateUsage = errors.New("invalid migrate command")
+
+// runMigrateCommand handles "server migrate ...". Errors are returned rather
+// than exiting here, so the database connection is closed first.
+func runMigrateCommand(args []string) error {
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+
+	// create only writes files, it needs neither config nor database
+	if args[0] == "create" {
+		if len(args) != 2 {
+			return errMigrateUsage
+		}
+		dir := os.Getenv("MIGRATIONS_DIR")
+		if dir == "" {
+			dir = "migrations"
+		}
+		up, down, err := migrate.Create(dir, args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Println("Created", up)
+		fmt.Println("Created", down)
+		return nil
+	}
+
+	// check the arguments before connecting, down rolls back one by default
+	fallback := 0
+	switch args[0] {
+	case "up", "status":
+	case "down":
+		fallback = 1
+	default:
+		return errMigrateUsage
+	}
+	n, err := countArg(args, fallback)
+	if err != nil {
+		return err
+	}
+
+	cfg := config.LoadConfig()
+	db, err := database.ConnectDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.CloseDB(db)
+
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up(ctx, n)
+		printMigrations("Applied", done)
+		return err
+	case "down":
+		done, err := migrator.Down(ctx, n)
+		printMigrations("Rolled back", done)
+		return err
+	default:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if status.Modified {
+				state += " (MODIFIED)"
+			}
+			fmt.Printf("%06d  %-40s %s\n", status.Version, status.Name, state)
+		}
+		return nil
+	}
+}
+
+// runMigrations applies pending migrations on startup
+func runMigrations(db *gorm.DB) error {
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	done, err := migrator.Up(context.Background(), 0)
+	for _, migration := range done {
+		pkgLogger.Info(fmt.Sprintf("Applied migration %06d_%s", migration.Version, migration.Name))
+	}
+	return err
+}
+
+// newMigrator uses the migrations embedded in the binary
+func newMigrator(db *gorm.DB) (*migrate.Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(sqlDB, migrations.FS), nil
+}
+
+// countArg parses the optional N argument
+func countArg(args []string, fallback int) (int, error) {
+	if len(args) < 2 {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 1 {
+		return 0, errMigrateUsage
+	}
+	return n, nil
+}
+
+func printMigrations(verb string, done []migrate.Migration) {
+	if len(done) == 0 {
+		fmt.Println("No migrations to run")
+	}
+	for _, migration := range done {
+		fmt.Printf("%s %06d_%s\n", verb, migration.Version, migration.Name)
+	}
+}
+
+// exitWithMigrateError is the single exit point of "server migrate"
+func exitWithMigrateError(err error) {
+	if errors.Is(err, errMigrateUsage) {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+	fmt.Fprintln(os.Stderr, "migrate:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/repositories"
+	pkgLogger "github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/logger"
+	"gorm.io/gorm"
+	"strconv"
+	"time"
+)
+
+// startWorkers starts the background jobs, they stop when shutdown begins
+func startWorkers(lc *lifecycle, db *gorm.DB) {
+	lc.Go("refresh token cleanup", func(ctx context.Context) {
+		refreshTokenRepo := repositories.NewRefreshTokenRepository(db)
+		every(ctx, time.Hour, func() {
+			deleted, err := refreshTokenRepo.DeleteExpired(ctx, time.Now())
+			if err != nil {
+				pkgLogger.Error("refresh token cleanup: " + err.Error())
+				return
+			}
+			if deleted > 0 {
+				pkgLogger.Info("Deleted " + strconv.FormatInt(deleted, 10) + " expired refresh tokens")
+			}
+		})
+	})
+
+	lc.Go("password reset token cleanup", func(ctx context.Context) {
+		resetTokenRepo := repositories.NewPasswordResetTokenRepository(db)
+		every(ctx, time.Hour, func() {
+			deleted, err := resetTokenRepo.DeleteExpired(ctx, time.Now())
+			if err != nil {
+				pkgLogger.Error("password reset token cleanup: " + err.Error())
+				return
+			}
+			if deleted > 0 {
+				pkgLogger.Info("Deleted " + strconv.FormatInt(deleted, 10) + " expired password reset tokens")
+			}
+		})
+	})
+}
+
+// every runs job on each tick until ctx is cancelled
+func every(ctx context.Context, interval time.Duration, job func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			job()
+		}
+	}
+}
@@ -3,19 +3,46 @@ package config
 import (
 	"github.com/spf13/viper"
 	"log"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	App      AppConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
+	App       AppConfig
+	Database  DatabaseConfig
+	Redis     RedisConfig
+	Cache     CacheConfig
+	RateLimit RateLimitConfig
+	Revoke    RevocationConfig
+	Health    HealthConfig
+	Tracing   TracingConfig
+	Mail      MailConfig
+	Verify    EmailVerificationConfig
+	Reset     PasswordResetConfig
+	Password  PasswordPolicyConfig
+	TwoFactor TwoFactorConfig
+	JWT       JWTConfig
 }
 
 type AppConfig struct {
 	Name string
 	Env  string
 	Port string
+
+	// LogLevel and LogFormat default per Env: debug/text in development, info/json in production
+	LogLevel  string
+	LogFormat string
+
+	// HTTP server timeouts, ShutdownTimeout bounds each graceful shutdown step
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+
+	// ProxyHeader carries the client IP set by a load balancer, e.g. X-Real-IP. It is only
+	// read on requests from TrustedProxies, so without proxies c.IP() is the peer address.
+	ProxyHeader    string
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
@@ -24,30 +51,268 @@ type DatabaseConfig struct {
 	User     string
 	Password string
 	Name     string
+
+	// MigrateOnStart applies pending SQL migrations when the server boots
+	MigrateOnStart bool
 }
 
 type RedisConfig struct {
-	Host string
-	Port string
+	Host     string
+	Port     string
+	Password string
+	DB       int
 }
 
-type JWTConfig struct {
+type CacheConfig struct {
+	// Driver is one of "redis", "memory" or "none"
+	Driver string
+	TTL    time.Duration
+}
+
+type RateLimitConfig struct {
+	// Driver is one of "redis" or "memory"
+	Driver string
+
+	// Login attempts allowed per Window, per client IP and per account
+	LoginPerIP      int
+	LoginPerAccount int
+	Window          time.Duration
+
+	// Progressive account lockout after LockoutThreshold failures
+	LockoutThreshold int
+	LockoutBase      time.Duration
+	LockoutMax       time.Duration
+	LockoutWindow    time.Duration
+}
+
+type RevocationConfig struct {
+	// Driver is one of "redis" or "memory", memory only revokes on the instance that handled the change
+	Driver string
+}
+
+type HealthConfig struct {
+	// Timeout bounds each readiness check
+	Timeout time.Duration
+
+	// Readiness is degraded when DiskPath has less than DiskMinFreeMB available
+	DiskPath      string
+	DiskMinFreeMB uint64
+}
+
+type TracingConfig struct {
+	// Exporter is otlp, stdout, file or none
+	Exporter     string
+	OTLPEndpoint string
+	OTLPInsecure bool
+	FilePath     string
+
+	// SampleRatio is the fraction of new traces recorded, between 0 and 1
+	SampleRatio float64
+}
+
+type MailConfig struct {
+	// Driver is smtp, log or file
+	Driver string
+	From   string
+
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// FileDir receives one .eml file per message with the file driver
+	FileDir string
+}
+
+type EmailVerificationConfig struct {
+	// Secret signs verification tokens, defaults to JWT_SECRET
+	Secret   string
+	TokenTTL time.Duration
+
+	// URL is the page the emailed link opens, the token is appended as ?token=
+	URL string
+
+	// ResendInterval is the minimum time between verification emails to one address
+	ResendInterval time.Duration
+}
+
+type PasswordResetConfig struct {
+	TokenTTL time.Duration
+
+	// URL is the page the emailed link opens, the token is appended as ?token=
+	URL string
+
+	// RequestInterval is the minimum time between reset emails to one address
+	RequestInterval time.Duration
+}
+
+type PasswordPolicyConfig struct {
+	MinLength int
+	MaxLength int
+
+	// Character classes a new password must contain
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+
+	// RejectCommon rejects passwords in the bundled common password list
+	RejectCommon bool
+
+	// Hasher is argon2id or bcrypt, hashes made otherwise are upgraded on login
+	Hasher string
+
+	// Argon2 cost parameters, Argon2Memory is in KiB
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+
+	BcryptCost int
+}
+
+type TwoFactorConfig struct {
+	// Issuer is the account label shown in authenticator apps, defaults to APP_NAME
+	Issuer string
+
+	// Secret encrypts stored TOTP secrets and signs login challenges, defaults to JWT_SECRET
 	Secret string
+
+	// ChallengeTTL is how long the second login step may take after the password
+	ChallengeTTL time.Duration
+
+	// RecoveryCodes is the number of single-use codes issued when 2FA is enabled
+	RecoveryCodes int
+}
+
+type JWTConfig struct {
+	// Secret is the default for the verification and two-factor secrets, access tokens use the key ring
+	Secret     string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+
+	// KeysDir holds one <kid>.pem RSA or Ed25519 key per file, ActiveKeyID picks the
+	// signing key and defaults to the greatest kid with a private key
+	KeysDir     string
+	ActiveKeyID string
+
+	// Issuer and Audience are set in every access token and required when validating
+	Issuer   string
+	Audience string
 }
 
 func LoadConfig() *Config {
 	viper.SetConfigFile(".env")
 	viper.AutomaticEnv()
 
+	// Defaults for optional settings
+	viper.SetDefault("DB_MIGRATE_ON_START", true)
+	viper.SetDefault("APP_READ_TIMEOUT", "10s")
+	viper.SetDefault("APP_WRITE_TIMEOUT", "10s")
+	viper.SetDefault("APP_IDLE_TIMEOUT", "60s")
+	viper.SetDefault("APP_SHUTDOWN_TIMEOUT", "15s")
+	viper.SetDefault("JWT_ACCESS_TTL", "15m")
+	viper.SetDefault("JWT_REFRESH_TTL", "720h")
+	viper.SetDefault("JWT_KEYS_DIR", "keys")
+	viper.SetDefault("CACHE_DRIVER", "redis")
+	viper.SetDefault("CACHE_TTL", "5m")
+	viper.SetDefault("RATE_LIMIT_DRIVER", "redis")
+	viper.SetDefault("REVOCATION_DRIVER", "redis")
+	viper.SetDefault("LOGIN_RATE_LIMIT_PER_IP", 20)
+	viper.SetDefault("LOGIN_RATE_LIMIT_PER_ACCOUNT", 10)
+	viper.SetDefault("LOGIN_RATE_LIMIT_WINDOW", "1m")
+	viper.SetDefault("LOGIN_LOCKOUT_THRESHOLD", 5)
+	viper.SetDefault("LOGIN_LOCKOUT_BASE", "1m")
+	viper.SetDefault("LOGIN_LOCKOUT_MAX", "1h")
+	viper.SetDefault("LOGIN_LOCKOUT_WINDOW", "15m")
+	viper.SetDefault("HEALTH_CHECK_TIMEOUT", "2s")
+	viper.SetDefault("HEALTH_DISK_PATH", "/")
+	viper.SetDefault("HEALTH_DISK_MIN_FREE_MB", 512)
+	viper.SetDefault("TRACING_EXPORTER", "none")
+	viper.SetDefault("TRACING_OTLP_ENDPOINT", "localhost:4318")
+	viper.SetDefault("TRACING_OTLP_INSECURE", true)
+	viper.SetDefault("TRACING_FILE_PATH", "traces.json")
+	viper.SetDefault("TRACING_SAMPLE_RATIO", 1.0)
+	viper.SetDefault("MAIL_DRIVER", "log")
+	viper.SetDefault("MAIL_FROM", "no-reply@localhost")
+	viper.SetDefault("SMTP_PORT", "587")
+	viper.SetDefault("MAIL_FILE_DIR", "mail")
+	viper.SetDefault("EMAIL_VERIFICATION_TTL", "24h")
+	viper.SetDefault("EMAIL_VERIFICATION_URL", "http://localhost:3000/verify-email")
+	viper.SetDefault("EMAIL_VERIFICATION_RESEND_INTERVAL", "1m")
+	viper.SetDefault("PASSWORD_RESET_TTL", "1h")
+	viper.SetDefault("PASSWORD_RESET_URL", "http://localhost:3000/reset-password")
+	viper.SetDefault("PASSWORD_RESET_REQUEST_INTERVAL", "1m")
+	viper.SetDefault("PASSWORD_MIN_LENGTH", 8)
+	viper.SetDefault("PASSWORD_MAX_LENGTH", 72)
+	viper.SetDefault("PASSWORD_REJECT_COMMON", true)
+	viper.SetDefault("PASSWORD_HASHER", "argon2id")
+	viper.SetDefault("ARGON2_MEMORY_KB", 65536)
+	viper.SetDefault("ARGON2_ITERATIONS", 3)
+	viper.SetDefault("ARGON2_PARALLELISM", 2)
+	viper.SetDefault("BCRYPT_COST", 14)
+	viper.SetDefault("TWO_FACTOR_CHALLENGE_TTL", "5m")
+	viper.SetDefault("TWO_FACTOR_RECOVERY_CODES", 10)
+
 	if err := viper.ReadInConfig(); err != nil {
 		log.Fatalf("Error reading config file, %s", err)
 	}
 
+	// Log defaults depend on APP_ENV, so they are set once it is known
+	if viper.GetString("APP_ENV") == "production" {
+		viper.SetDefault("LOG_LEVEL", "info")
+		viper.SetDefault("LOG_FORMAT", "json")
+	} else {
+		viper.SetDefault("LOG_LEVEL", "debug")
+		viper.SetDefault("LOG_FORMAT", "text")
+	}
+
+	// Verification tokens are signed with the JWT secret unless a dedicated one is set
+	verificationSecret := viper.GetString("EMAIL_VERIFICATION_SECRET")
+	if verificationSecret == "" {
+		verificationSecret = viper.GetString("JWT_SECRET")
+	}
+
+	// Two-factor secrets are keyed by the JWT secret unless a dedicated one is set
+	twoFactorSecret := viper.GetString("TWO_FACTOR_SECRET")
+	if twoFactorSecret == "" {
+		twoFactorSecret = viper.GetString("JWT_SECRET")
+	}
+
+	// Both keep working from JWT_SECRET alone, so an empty or placeholder value must not slip through
+	requireSecret("EMAIL_VERIFICATION_SECRET", verificationSecret)
+	requireSecret("TWO_FACTOR_SECRET", twoFactorSecret)
+
+	twoFactorIssuer := viper.GetString("TWO_FACTOR_ISSUER")
+	if twoFactorIssuer == "" {
+		twoFactorIssuer = viper.GetString("APP_NAME")
+	}
+
+	// Access tokens are issued by and for this app unless configured otherwise
+	jwtIssuer := viper.GetString("JWT_ISSUER")
+	if jwtIssuer == "" {
+		jwtIssuer = viper.GetString("APP_NAME")
+	}
+	jwtAudience := viper.GetString("JWT_AUDIENCE")
+	if jwtAudience == "" {
+		jwtAudience = jwtIssuer
+	}
+
 	return &Config{
 		App: AppConfig{
 			Name: viper.GetString("APP_NAME"),
 			Env:  viper.GetString("APP_ENV"),
 			Port: viper.GetString("APP_PORT"),
+
+			LogLevel:  viper.GetString("LOG_LEVEL"),
+			LogFormat: viper.GetString("LOG_FORMAT"),
+
+			ReadTimeout:     viper.GetDuration("APP_READ_TIMEOUT"),
+			WriteTimeout:    viper.GetDuration("APP_WRITE_TIMEOUT"),
+			IdleTimeout:     viper.GetDuration("APP_IDLE_TIMEOUT"),
+			ShutdownTimeout: viper.GetDuration("APP_SHUTDOWN_TIMEOUT"),
+
+			ProxyHeader:    viper.GetString("APP_PROXY_HEADER"),
+			TrustedProxies: splitList(viper.GetString("APP_TRUSTED_PROXIES")),
 		},
 		Database: DatabaseConfig{
 			Host:     viper.GetString("DB_HOST"),
@@ -55,13 +320,116 @@ func LoadConfig() *Config {
 			User:     viper.GetString("DB_USER"),
 			Password: viper.GetString("DB_PASSWORD"),
 			Name:     viper.GetString("DB_NAME"),
+
+			MigrateOnStart: viper.GetBool("DB_MIGRATE_ON_START"),
 		},
 		Redis: RedisConfig{
-			Host: viper.GetString("REDIS_HOST"),
-			Port: viper.GetString("REDIS_PORT"),
+			Host:     viper.GetString("REDIS_HOST"),
+			Port:     viper.GetString("REDIS_PORT"),
+			Password: viper.GetString("REDIS_PASSWORD"),
+			DB:       viper.GetInt("REDIS_DB"),
+		},
+		Cache: CacheConfig{
+			Driver: viper.GetString("CACHE_DRIVER"),
+			TTL:    viper.GetDuration("CACHE_TTL"),
+		},
+		RateLimit: RateLimitConfig{
+			Driver:           viper.GetString("RATE_LIMIT_DRIVER"),
+			LoginPerIP:       viper.GetInt("LOGIN_RATE_LIMIT_PER_IP"),
+			LoginPerAccount:  viper.GetInt("LOGIN_RATE_LIMIT_PER_ACCOUNT"),
+			Window:           viper.GetDuration("LOGIN_RATE_LIMIT_WINDOW"),
+			LockoutThreshold: viper.GetInt("LOGIN_LOCKOUT_THRESHOLD"),
+			LockoutBase:      viper.GetDuration("LOGIN_LOCKOUT_BASE"),
+			LockoutMax:       viper.GetDuration("LOGIN_LOCKOUT_MAX"),
+			LockoutWindow:    viper.GetDuration("LOGIN_LOCKOUT_WINDOW"),
+		},
+		Revoke: RevocationConfig{
+			Driver: viper.GetString("REVOCATION_DRIVER"),
+		},
+		Health: HealthConfig{
+			Timeout:       viper.GetDuration("HEALTH_CHECK_TIMEOUT"),
+			DiskPath:      viper.GetString("HEALTH_DISK_PATH"),
+			DiskMinFreeMB: viper.GetUint64("HEALTH_DISK_MIN_FREE_MB"),
+		},
+		Tracing: TracingConfig{
+			Exporter:     viper.GetString("TRACING_EXPORTER"),
+			OTLPEndpoint: viper.GetString("TRACING_OTLP_ENDPOINT"),
+			OTLPInsecure: viper.GetBool("TRACING_OTLP_INSECURE"),
+			FilePath:     viper.GetString("TRACING_FILE_PATH"),
+			SampleRatio:  viper.GetFloat64("TRACING_SAMPLE_RATIO"),
+		},
+		Mail: MailConfig{
+			Driver:       viper.GetString("MAIL_DRIVER"),
+			From:         viper.GetString("MAIL_FROM"),
+			SMTPHost:     viper.GetString("SMTP_HOST"),
+			SMTPPort:     viper.GetString("SMTP_PORT"),
+			SMTPUsername: viper.GetString("SMTP_USERNAME"),
+			SMTPPassword: viper.GetString("SMTP_PASSWORD"),
+			FileDir:      viper.GetString("MAIL_FILE_DIR"),
+		},
+		Verify: EmailVerificationConfig{
+			Secret:         verificationSecret,
+			TokenTTL:       viper.GetDuration("EMAIL_VERIFICATION_TTL"),
+			URL:            viper.GetString("EMAIL_VERIFICATION_URL"),
+			ResendInterval: viper.GetDuration("EMAIL_VERIFICATION_RESEND_INTERVAL"),
+		},
+		Reset: PasswordResetConfig{
+			TokenTTL:        viper.GetDuration("PASSWORD_RESET_TTL"),
+			URL:             viper.GetString("PASSWORD_RESET_URL"),
+			RequestInterval: viper.GetDuration("PASSWORD_RESET_REQUEST_INTERVAL"),
+		},
+		Password: PasswordPolicyConfig{
+			MinLength: viper.GetInt("PASSWORD_MIN_LENGTH"),
+			MaxLength: viper.GetInt("PASSWORD_MAX_LENGTH"),
+
+			RequireUpper:  viper.GetBool("PASSWORD_REQUIRE_UPPER"),
+			RequireLower:  viper.GetBool("PASSWORD_REQUIRE_LOWER"),
+			RequireDigit:  viper.GetBool("PASSWORD_REQUIRE_DIGIT"),
+			RequireSymbol: viper.GetBool("PASSWORD_REQUIRE_SYMBOL"),
+			RejectCommon:  viper.GetBool("PASSWORD_REJECT_COMMON"),
+
+			Hasher:            viper.GetString("PASSWORD_HASHER"),
+			Argon2Memory:      viper.GetUint32("ARGON2_MEMORY_KB"),
+			Argon2Iterations:  viper.GetUint32("ARGON2_ITERATIONS"),
+			Argon2Parallelism: uint8(viper.GetUint("ARGON2_PARALLELISM")),
+			BcryptCost:        viper.GetInt("BCRYPT_COST"),
+		},
+		TwoFactor: TwoFactorConfig{
+			Issuer:        twoFactorIssuer,
+			Secret:        twoFactorSecret,
+			ChallengeTTL:  viper.GetDuration("TWO_FACTOR_CHALLENGE_TTL"),
+			RecoveryCodes: viper.GetInt("TWO_FACTOR_RECOVERY_CODES"),
 		},
 		JWT: JWTConfig{
-			Secret: viper.GetString("JWT_SECRET"),
+			Secret:     viper.GetString("JWT_SECRET"),
+			AccessTTL:  viper.GetDuration("JWT_ACCESS_TTL"),
+			RefreshTTL: viper.GetDuration("JWT_REFRESH_TTL"),
+
+			KeysDir:     viper.GetString("JWT_KEYS_DIR"),
+			ActiveKeyID: viper.GetString("JWT_ACTIVE_KID"),
+			Issuer:      jwtIssuer,
+			Audience:    jwtAudience,
 		},
 	}
 }
+
+// minSecretLength is the shortest secret accepted as HMAC and AES-GCM key material
+const minSecretLength = 32
+
+// requireSecret stops startup when a secret, after its JWT_SECRET fallback, is missing or too short
+func requireSecret(name, value string) {
+	if len(value) < minSecretLength {
+		log.Fatalf("%s, or JWT_SECRET when it is unset, must be at least %d bytes", name, minSecretLength)
+	}
+}
+
+// splitList parses a comma separated setting, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -7,9 +7,8 @@ import (
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
-
-func ConnectDB(cfg *config.Config) {
+// ConnectDB opens the connection pool, callers pass the handle to the repositories
+func ConnectDB(cfg *config.Config) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
 		cfg.Database.Host,
 		cfg.Database.User,
@@ -18,11 +17,17 @@ func ConnectDB(cfg *config.Config) {
 		cfg.Database.Port,
 	)
 
-	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{
+		// map driver errors such as unique violations to gorm.ErrDuplicatedKey
+		TranslateError: true,
+	})
+}
+
+// CloseDB closes the connection pool
+func CloseDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
 	if err != nil {
-		panic(err)
+		return err
 	}
-
-	fmt.Println("Connected to database")
+	return sqlDB.Close()
 }
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"context"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/config"
+	"github.com/redis/go-redis/v9"
+	"net"
+	"time"
+)
+
+// ConnectRedis creates a Redis client and checks the connection
+func ConnectRedis(cfg *config.Config) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     net.JoinHostPort(cfg.Redis.Host, cfg.Redis.Port),
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
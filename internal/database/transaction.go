@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"gorm.io/gorm"
+)
+
+// txKey is the context key holding the active transaction
+type txKey struct{}
+
+// TxManager runs several repository calls in one database transaction
+type TxManager struct {
+	db *gorm.DB
+}
+
+// NewTxManager create new TxManager instance
+func NewTxManager(db *gorm.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+// WithinTransaction runs fn in a transaction carried by the ctx passed to it.
+// Returning an error or panicking rolls back, otherwise the transaction commits.
+// Calling it again inside fn creates a savepoint, so an inner failure only
+// rolls back the inner block when the outer fn handles the error.
+func (m *TxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return Conn(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// Conn returns the transaction carried by ctx, or db when there is none.
+// Repositories use it for every query so they join the caller's transaction.
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
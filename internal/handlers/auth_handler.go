@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/schemas"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/response"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/validator"
@@ -9,9 +10,13 @@ import (
 
 // AuthServiceInterface defines what auth handler needs from service
 type AuthServiceInterface interface {
-	Register(req *schemas.RegisterRequest) (*schemas.AuthResponse, error)
-	Login(req *schemas.LoginRequest) (*schemas.AuthResponse, error)
-	GetProfile(userID uint) (*schemas.UserResponse, error)
+	Register(ctx context.Context, req *schemas.RegisterRequest) (*schemas.UserResponse, error)
+	Login(ctx context.Context, req *schemas.LoginRequest) (*schemas.LoginResponse, error)
+	VerifyTwoFactor(ctx context.Context, req *schemas.TwoFactorVerifyRequest) (*schemas.AuthResponse, error)
+	Refresh(ctx context.Context, req *schemas.RefreshTokenRequest) (*schemas.AuthResponse, error)
+	Logout(ctx context.Context, req *schemas.LogoutRequest) error
+	ChangePassword(ctx context.Context, userID uint, req *schemas.ChangePasswordRequest) (*schemas.AuthResponse, error)
+	GetProfile(ctx context.Context, userID uint) (*schemas.UserResponse, error)
 }
 
 // AuthHandler handles http request for authentication
@@ -41,12 +46,12 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	}
 
 	// call service
-	result, err := h.authService.Register(&req)
+	result, err := h.authService.Register(c.UserContext(), &req)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return err
 	}
 
-	return response.Created(c, "User registered successfully", result)
+	return response.Created(c, "User registered successfully, check your email to verify the account", result)
 }
 
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
@@ -59,22 +64,105 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		return response.ValidationFailed(c, "Validation failed", errors)
 	}
 
-	result, err := h.authService.Login(&req)
+	result, err := h.authService.Login(c.UserContext(), &req)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return err
+	}
+	if result.TwoFactor != nil {
+		return response.Success(c, "Two-factor code required", result)
+	}
+
+	return response.Success(c, "User logged in successfully", result)
+}
+
+// VerifyTwoFactor handles POST /auth/2fa/verify
+func (h *AuthHandler) VerifyTwoFactor(c *fiber.Ctx) error {
+	var req schemas.TwoFactorVerifyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if errors := validator.ValidateStruct(req); errors != nil {
+		return response.ValidationFailed(c, "Validation failed", errors)
+	}
+
+	result, err := h.authService.VerifyTwoFactor(c.UserContext(), &req)
+	if err != nil {
+		return err
 	}
 
 	return response.Success(c, "User logged in successfully", result)
 }
 
+// Refresh handles POST /auth/refresh
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var req schemas.RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if errors := validator.ValidateStruct(req); errors != nil {
+		return response.ValidationFailed(c, "Validation failed", errors)
+	}
+
+	result, err := h.authService.Refresh(c.UserContext(), &req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Token refreshed successfully", result)
+}
+
+// Logout handles POST /auth/logout
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	var req schemas.LogoutRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if errors := validator.ValidateStruct(req); errors != nil {
+		return response.ValidationFailed(c, "Validation failed", errors)
+	}
+
+	if err := h.authService.Logout(c.UserContext(), &req); err != nil {
+		return err
+	}
+
+	return response.Success(c, "User logged out successfully", nil)
+}
+
+// ChangePassword handles POST /auth/change-password
+func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return response.BadRequest(c, "User ID not found in context")
+	}
+
+	var req schemas.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if errors := validator.ValidateStruct(req); errors != nil {
+		return response.ValidationFailed(c, "Validation failed", errors)
+	}
+
+	result, err := h.authService.ChangePassword(c.UserContext(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Password changed successfully, other sessions have been signed out", result)
+}
+
 func (h *AuthHandler) GetProfile(c *fiber.Ctx) error {
 	userID, ok := c.Locals("user_id").(uint)
 	if !ok {
 		return response.BadRequest(c, "User ID not found in context")
 	}
-	user, err := h.authService.GetProfile(userID)
+	user, err := h.authService.GetProfile(c.UserContext(), userID)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return err
 	}
 
 	return response.Success(c, "Profile retrieved successfully", user)
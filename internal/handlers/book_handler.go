@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"context"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/middleware"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/policy"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/schemas"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/cache"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/response"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/utils"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/validator"
@@ -11,11 +15,11 @@ import (
 
 // BookServiceInterface defines what book handler need from service
 type BookServiceInterface interface {
-	Create(req *schemas.CreateBookRequest, userId uint) (*schemas.BookResponse, error)
-	GetById(id uint) (*schemas.BookResponse, error)
-	GetAll(params *utils.PaginationParams) ([]schemas.BookResponse, *response.Pagination, error)
-	Update(id uint, req *schemas.UpdateBookRequest) (*schemas.BookResponse, error)
-	Delete(id uint) error
+	Create(ctx context.Context, req *schemas.CreateBookRequest, userId uint) (*schemas.BookResponse, error)
+	GetById(ctx context.Context, id uint) (*schemas.BookResponse, cache.Status, error)
+	GetAll(ctx context.Context, params *utils.PaginationParams) ([]schemas.BookResponse, *response.Pagination, cache.Status, error)
+	Update(ctx context.Context, actor policy.Actor, id uint, req *schemas.UpdateBookRequest) (*schemas.BookResponse, error)
+	Delete(ctx context.Context, actor policy.Actor, id uint) error
 }
 
 // BookHandler handles http request for book management
@@ -48,9 +52,9 @@ func (h *BookHandler) Create(c *fiber.Ctx) error {
 	}
 
 	// call service
-	book, err := h.bookService.Create(&req, userID)
+	book, err := h.bookService.Create(c.UserContext(), &req, userID)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return err
 	}
 
 	return response.Success(c, "Success create book", book)
@@ -68,42 +72,37 @@ func (h *BookHandler) GetById(c *fiber.Ctx) error {
 	id := uint(idInt)
 
 	// get book from service
-	book, err := h.bookService.GetById(id)
+	book, cacheStatus, err := h.bookService.GetById(c.UserContext(), id)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return err
 	}
+	c.Set(cache.Header, string(cacheStatus))
 
 	return response.Success(c, "Success get book", book)
 }
 
 func (h *BookHandler) GetAll(c *fiber.Ctx) error {
-	page, err := strconv.Atoi(c.Query("page", "1"))
-	if err != nil {
-		return response.BadRequest(c, err.Error())
-	}
-
-	size, err := strconv.Atoi(c.Query("size", "10"))
+	// parse pagination, sorting and filters against the whitelist
+	params, err := utils.NewPaginationParams(c.Queries(), schemas.BookQuerySpec)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return err
 	}
 
-	params := &utils.PaginationParams{
-		Page:   page,
-		Size:   size,
-		Sort:   c.Query("sort", ""),
-		Order:  c.Query("order", ""),
-		Search: c.Query("search", ""),
-	}
-
-	books, pagination, err := h.bookService.GetAll(params)
+	books, pagination, cacheStatus, err := h.bookService.GetAll(c.UserContext(), params)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return err
 	}
+	c.Set(cache.Header, string(cacheStatus))
 
 	return response.Paginated(c, "Books retrieved successfully", books, *pagination)
 }
 
 func (h *BookHandler) Update(c *fiber.Ctx) error {
+	actor, ok := middleware.CurrentActor(c)
+	if !ok {
+		return response.BadRequest(c, "User ID is not in context.")
+	}
+
 	// get int userId
 	idInt, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
@@ -122,15 +121,20 @@ func (h *BookHandler) Update(c *fiber.Ctx) error {
 	id := uint(idInt)
 
 	// get book by id
-	book, err := h.bookService.Update(id, &req)
+	book, err := h.bookService.Update(c.UserContext(), actor, id, &req)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return err
 	}
 
 	return response.Success(c, "Success update book", book)
 }
 
 func (h *BookHandler) Delete(c *fiber.Ctx) error {
+	actor, ok := middleware.CurrentActor(c)
+	if !ok {
+		return response.BadRequest(c, "User ID is not in context.")
+	}
+
 	// get int userId
 	idInt, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
@@ -139,9 +143,9 @@ func (h *BookHandler) Delete(c *fiber.Ctx) error {
 
 	id := uint(idInt)
 
-	err = h.bookService.Delete(id)
+	err = h.bookService.Delete(c.UserContext(), actor, id)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return err
 	}
 
 	return response.Success(c, "Success delete book", id)
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/health"
+	"github.com/gofiber/fiber/v2"
+	"time"
+)
+
+// HealthHandler handles liveness and readiness probes
+type HealthHandler struct {
+	registry *health.Registry
+}
+
+// NewHealthHandler create new HealthHandler instance
+func NewHealthHandler(registry *health.Registry) *HealthHandler {
+	return &HealthHandler{registry: registry}
+}
+
+// Live handles GET /health/live
+// It only reports that the process is serving requests and never checks dependencies
+func (h *HealthHandler) Live(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status":    health.StatusUp,
+		"timestamp": time.Now().UTC(),
+	})
+}
+
+// Ready handles GET /health/ready
+// It runs every registered check and returns 503 when a critical one fails
+func (h *HealthHandler) Ready(c *fiber.Ctx) error {
+	report := h.registry.Run(c.UserContext())
+
+	status := fiber.StatusOK
+	if report.Status == health.StatusDown {
+		status = fiber.StatusServiceUnavailable
+	}
+
+	return c.Status(status).JSON(report)
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/jwt"
+	"github.com/gofiber/fiber/v2"
+)
+
+// JWKSHandler publishes the public keys that verify access tokens
+type JWKSHandler struct {
+	keys *jwt.KeyRing
+}
+
+// NewJWKSHandler create new JWKSHandler instance
+func NewJWKSHandler(keys *jwt.KeyRing) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// Get handles GET /.well-known/jwks.json
+// Verifiers may cache the set for a few minutes, so a new key is added here before it signs
+func (h *JWKSHandler) Get(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(h.keys.JWKS())
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/schemas"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/response"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/validator"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PasswordResetServiceInterface defines what password handler needs from the reset service
+type PasswordResetServiceInterface interface {
+	ForgotPassword(ctx context.Context, req *schemas.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req *schemas.ResetPasswordRequest) error
+}
+
+// PasswordHandler handles http request for password recovery
+type PasswordHandler struct {
+	resetService PasswordResetServiceInterface
+}
+
+// NewPasswordHandler create new PasswordHandler instance
+func NewPasswordHandler(resetService PasswordResetServiceInterface) *PasswordHandler {
+	return &PasswordHandler{
+		resetService: resetService,
+	}
+}
+
+// ForgotPassword handles POST /auth/forgot-password
+func (h *PasswordHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req schemas.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if errors := validator.ValidateStruct(req); errors != nil {
+		return response.ValidationFailed(c, "Validation failed", errors)
+	}
+
+	if err := h.resetService.ForgotPassword(c.UserContext(), &req); err != nil {
+		return err
+	}
+
+	return response.Success(c, "If the account exists, a password reset email has been sent", nil)
+}
+
+// ResetPassword handles POST /auth/reset-password
+func (h *PasswordHandler) ResetPassword(c *fiber.Ctx) error {
+	var req schemas.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if errors := validator.ValidateStruct(req); errors != nil {
+		return response.ValidationFailed(c, "Validation failed", errors)
+	}
+
+	if err := h.resetService.ResetPassword(c.UserContext(), &req); err != nil {
+		return err
+	}
+
+	return response.Success(c, "Password reset successfully, please log in again", nil)
+}
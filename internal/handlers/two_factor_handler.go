@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/schemas"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/response"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/validator"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TwoFactorServiceInterface defines what two-factor handler needs from service
+type TwoFactorServiceInterface interface {
+	Enroll(ctx context.Context, userID uint) (*schemas.TwoFactorEnrollResponse, error)
+	Confirm(ctx context.Context, userID uint, req *schemas.TwoFactorConfirmRequest) (*schemas.TwoFactorRecoveryCodesResponse, error)
+}
+
+// TwoFactorHandler handles http request for two-factor enrollment
+type TwoFactorHandler struct {
+	twoFactorService TwoFactorServiceInterface
+}
+
+// NewTwoFactorHandler create new TwoFactorHandler instance
+func NewTwoFactorHandler(twoFactorService TwoFactorServiceInterface) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+	}
+}
+
+// Enroll handles POST /auth/2fa/enroll
+func (h *TwoFactorHandler) Enroll(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return response.BadRequest(c, "User ID not found in context")
+	}
+
+	result, err := h.twoFactorService.Enroll(c.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Add the secret to your authenticator app, then confirm with a code", result)
+}
+
+// Confirm handles POST /auth/2fa/confirm
+func (h *TwoFactorHandler) Confirm(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return response.BadRequest(c, "User ID not found in context")
+	}
+
+	var req schemas.TwoFactorConfirmRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if errors := validator.ValidateStruct(req); errors != nil {
+		return response.ValidationFailed(c, "Validation failed", errors)
+	}
+
+	result, err := h.twoFactorService.Confirm(c.UserContext(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Two-factor authentication enabled, store the recovery codes somewhere safe", result)
+}
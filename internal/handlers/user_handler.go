@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"context"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/middleware"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/policy"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/schemas"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/response"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/utils"
@@ -11,10 +14,10 @@ import (
 
 // UserServiceInterface defines what user handler needs from service
 type UserServiceInterface interface {
-	GetAll(params *utils.PaginationParams) ([]schemas.UserResponse, *response.Pagination, error)
-	GetByID(id uint) (*schemas.UserResponse, error)
-	Update(id uint, req *schemas.UpdateUserRequest) (*schemas.UserResponse, error)
-	Delete(id uint) error
+	GetAll(ctx context.Context, actor policy.Actor, params *utils.PaginationParams) ([]schemas.UserResponse, *response.Pagination, error)
+	GetByID(ctx context.Context, actor policy.Actor, id uint) (*schemas.UserResponse, error)
+	Update(ctx context.Context, actor policy.Actor, id uint, req *schemas.UpdateUserRequest) (*schemas.UserResponse, error)
+	Delete(ctx context.Context, actor policy.Actor, id uint) error
 }
 
 // UserHandler handles http request for user management
@@ -30,33 +33,31 @@ func NewUserHandler(userService UserServiceInterface) *UserHandler {
 }
 
 func (h *UserHandler) GetAll(c *fiber.Ctx) error {
-	page, err := strconv.Atoi(c.Query("page", "1"))
-	if err != nil || page < 1 {
-		page = 1
+	actor, ok := middleware.CurrentActor(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
 	}
 
-	size, err := strconv.Atoi(c.Query("size", "10"))
-	if err != nil || size < 1 {
-		size = 10
-	}
-
-	params := &utils.PaginationParams{
-		Page:   page,
-		Size:   size,
-		Sort:   c.Query("sort", ""),
-		Order:  c.Query("order", ""),
-		Search: c.Query("search", ""),
+	// parse pagination, sorting and filters against the whitelist
+	params, err := utils.NewPaginationParams(c.Queries(), schemas.UserQuerySpec)
+	if err != nil {
+		return err
 	}
 
-	users, pagination, err := h.userService.GetAll(params)
+	users, pagination, err := h.userService.GetAll(c.UserContext(), actor, params)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return err
 	}
 
 	return response.Paginated(c, "Users rerieved successfully", users, *pagination)
 }
 
 func (h *UserHandler) GetByID(c *fiber.Ctx) error {
+	actor, ok := middleware.CurrentActor(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
 	// Parse int
 	idInt, err := strconv.Atoi(c.Params("id"))
 	if err != nil || idInt <= 0 {
@@ -66,15 +67,20 @@ func (h *UserHandler) GetByID(c *fiber.Ctx) error {
 	// Convert int ke uint
 	id := uint(idInt)
 
-	user, err := h.userService.GetByID(id)
+	user, err := h.userService.GetByID(c.UserContext(), actor, id)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return err
 	}
 
 	return response.Success(c, "User retrieved successfully", user)
 }
 
 func (h *UserHandler) Update(c *fiber.Ctx) error {
+	actor, ok := middleware.CurrentActor(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
 	// parse id int
 	idInt, err := strconv.Atoi(c.Params("id"))
 	if err != nil || idInt <= 0 {
@@ -92,9 +98,9 @@ func (h *UserHandler) Update(c *fiber.Ctx) error {
 
 	id := uint(idInt)
 
-	user, err := h.userService.Update(id, &req)
+	user, err := h.userService.Update(c.UserContext(), actor, id, &req)
 	if err != nil {
-		return response.BadRequest(c, err.Error())
+		return err
 	}
 
 	return response.Success(c, "User updated successfully", user)
@@ -102,6 +108,11 @@ func (h *UserHandler) Update(c *fiber.Ctx) error {
 }
 
 func (h *UserHandler) Delete(c *fiber.Ctx) error {
+	actor, ok := middleware.CurrentActor(c)
+	if !ok {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
 	idInt, err := strconv.Atoi(c.Params("id"))
 	if err != nil || idInt <= 0 {
 		return response.BadRequest(c, "Invalid ID")
@@ -109,9 +120,8 @@ func (h *UserHandler) Delete(c *fiber.Ctx) error {
 
 	id := uint(idInt)
 
-	error := h.userService.Delete(id)
-	if error != nil {
-		return response.BadRequest(c, error.Error())
+	if err := h.userService.Delete(c.UserContext(), actor, id); err != nil {
+		return err
 	}
 
 	return response.Success(c, "User deleted successfully", nil)
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/schemas"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/response"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/validator"
+	"github.com/gofiber/fiber/v2"
+)
+
+// VerificationServiceInterface defines what verification handler needs from service
+type VerificationServiceInterface interface {
+	Verify(ctx context.Context, req *schemas.VerifyEmailRequest) (*schemas.UserResponse, error)
+	Resend(ctx context.Context, req *schemas.ResendVerificationRequest) error
+}
+
+// VerificationHandler handles http request for email verification
+type VerificationHandler struct {
+	verificationService VerificationServiceInterface
+}
+
+// NewVerificationHandler create new VerificationHandler instance
+func NewVerificationHandler(verificationService VerificationServiceInterface) *VerificationHandler {
+	return &VerificationHandler{
+		verificationService: verificationService,
+	}
+}
+
+// VerifyEmail handles POST /auth/verify-email
+func (h *VerificationHandler) VerifyEmail(c *fiber.Ctx) error {
+	var req schemas.VerifyEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if errors := validator.ValidateStruct(req); errors != nil {
+		return response.ValidationFailed(c, "Validation failed", errors)
+	}
+
+	user, err := h.verificationService.Verify(c.UserContext(), &req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Email verified successfully", user)
+}
+
+// Resend handles POST /auth/resend-verification
+func (h *VerificationHandler) Resend(c *fiber.Ctx) error {
+	var req schemas.ResendVerificationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body")
+	}
+
+	if errors := validator.ValidateStruct(req); errors != nil {
+		return response.ValidationFailed(c, "Validation failed", errors)
+	}
+
+	if err := h.verificationService.Resend(c.UserContext(), &req); err != nil {
+		return err
+	}
+
+	return response.Success(c, "If the account exists and is not verified yet, a verification email has been sent", nil)
+}
@@ -1,14 +1,29 @@
 package middleware
 
 import (
+	"context"
+	"fmt"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/jwt"
+	pkgLogger "github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/logger"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/response"
 	"github.com/gofiber/fiber/v2"
 	"strings"
+	"time"
 )
 
+// TokenValidator verifies access tokens
+type TokenValidator interface {
+	ValidateToken(token string) (*jwt.Claims, error)
+}
+
+// RevocationChecker reports whether a valid token has been revoked before its expiry
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, jti string, userID uint, issuedAt time.Time) (bool, error)
+}
+
 // AuthMiddleware validates JWT token
-func AuthMiddleware(jwtSecret string) fiber.Handler {
+// A revocation check that fails rejects the request, a revoked user must not get through while the store is down
+func AuthMiddleware(tokens TokenValidator, revoked RevocationChecker) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Get Authorization header
 		authHeader := c.Get("Authorization")
@@ -20,15 +35,26 @@ func AuthMiddleware(jwtSecret string) fiber.Handler {
 		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
 
 		// Validate token
-		claims, err := jwt.ValidateToken(tokenString, jwtSecret)
+		claims, err := tokens.ValidateToken(tokenString)
 		if err != nil {
 			return response.Unauthorized(c, err.Error())
 		}
 
+		isRevoked, err := revoked.IsRevoked(c.UserContext(), claims.ID, claims.UserID, claims.IssuedAtTime())
+		if err != nil {
+			return fmt.Errorf("check token revocation: %w", err)
+		}
+		if isRevoked {
+			return response.Unauthorized(c, "token has been revoked")
+		}
+
 		// Set user info in context for use in handler
 		c.Locals("user_id", claims.UserID)
 		c.Locals("user_email", claims.Email)
 		c.Locals("user_role", claims.Role)
+		c.SetUserContext(pkgLogger.WithFields(c.UserContext(), pkgLogger.Fields{
+			"user_id": claims.UserID,
+		}))
 
 		return c.Next()
 	}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"time"
+)
+
+// HTTPObserver records request latency, implemented by metrics.Metrics
+type HTTPObserver interface {
+	ObserveHTTP(method, route string, status int, duration time.Duration)
+}
+
+// Metrics records every request labelled by its route template, not the raw
+// path, so IDs in URLs do not create new series
+func Metrics(observer HTTPObserver) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		nextWithErrorHandler(c)
+
+		observer.ObserveHTTP(c.Method(), c.Route().Path, c.Response().StatusCode(), time.Since(start))
+		return nil
+	}
+}
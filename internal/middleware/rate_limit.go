@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/ratelimit"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/response"
+	"github.com/gofiber/fiber/v2"
+	"math"
+	"strconv"
+)
+
+// KeyFunc derives the rate limit key from a request, an empty key skips limiting
+type KeyFunc func(c *fiber.Ctx) string
+
+// KeyByIP limits per client IP. Behind a load balancer the client IP is only
+// known when APP_PROXY_HEADER and APP_TRUSTED_PROXIES are set, otherwise all
+// clients share the balancer's bucket.
+func KeyByIP(c *fiber.Ctx) string {
+	return "ip:" + c.IP()
+}
+
+// KeyByBodyField limits per value of a JSON body field, e.g. the login email
+func KeyByBodyField(field string) KeyFunc {
+	return func(c *fiber.Ctx) string {
+		var body map[string]interface{}
+		if err := json.Unmarshal(c.Body(), &body); err != nil {
+			return ""
+		}
+		value, ok := body[field].(string)
+		if !ok || value == "" {
+			return ""
+		}
+		return field + ":" + ratelimit.NormalizeKey(value)
+	}
+}
+
+// RateLimit rejects requests over the limiter's limit with 429.
+// Backend errors reject the request, an outage must not turn brute-force protection off.
+func RateLimit(name string, limiter *ratelimit.Limiter, keyFunc KeyFunc) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := keyFunc(c)
+		if key == "" {
+			return c.Next()
+		}
+
+		result, err := limiter.Allow(c.UserContext(), name+":"+key)
+		if err != nil {
+			return fmt.Errorf("rate limit %s: %w", name, err)
+		}
+
+		c.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+
+		if !result.Allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			return response.TooManyRequests(c, "Too many requests, please try again later")
+		}
+
+		return c.Next()
+	}
+}
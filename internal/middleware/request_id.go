@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	pkgLogger "github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// maxRequestIDLength bounds client supplied request IDs
+const maxRequestIDLength = 128
+
+// RequestID tags each request with an ID, reusing a valid X-Request-ID from the client.
+// The ID is echoed in the response and attached to the request scoped logger.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(fiber.HeaderXRequestID)
+		if !isValidRequestID(requestID) {
+			requestID = utils.UUIDv4()
+		}
+
+		c.Set(fiber.HeaderXRequestID, requestID)
+		c.Locals("request_id", requestID)
+		c.SetUserContext(pkgLogger.WithFields(c.UserContext(), pkgLogger.Fields{
+			"request_id": requestID,
+		}))
+
+		return c.Next()
+	}
+}
+
+// isValidRequestID accepts printable ASCII so IDs cannot inject into logs
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < 0x21 || requestID[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	pkgLogger "github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"time"
+)
+
+// RequestLogger writes one structured access log entry per request.
+// It must run after RequestID so the entry carries the request ID.
+func RequestLogger() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		nextWithErrorHandler(c)
+
+		status := c.Response().StatusCode()
+		entry := pkgLogger.FromContext(c.UserContext()).WithFields(pkgLogger.Fields{
+			"method":     c.Method(),
+			"path":       c.Path(),
+			"route":      c.Route().Path,
+			"status":     status,
+			"latency_ms": time.Since(start).Milliseconds(),
+			"ip":         c.IP(),
+		})
+
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			entry.Error("request completed")
+		case status >= fiber.StatusBadRequest:
+			entry.Warn("request completed")
+		default:
+			entry.Info("request completed")
+		}
+
+		return nil
+	}
+}
+
+// nextWithErrorHandler runs the rest of the chain and answers its error right
+// away, so middleware that reads the response afterwards sees the status that
+// is sent. It returns the handler's error for middleware that records it.
+func nextWithErrorHandler(c *fiber.Ctx) error {
+	err := c.Next()
+	if err != nil {
+		if err := c.App().ErrorHandler(c, err); err != nil {
+			_ = c.SendStatus(fiber.StatusInternalServerError)
+		}
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/policy"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/response"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+)
+
+// CurrentActor builds the acting user from the locals set by AuthMiddleware
+func CurrentActor(c *fiber.Ctx) (policy.Actor, bool) {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return policy.Actor{}, false
+	}
+	role, _ := c.Locals("user_role").(string)
+
+	return policy.Actor{UserID: userID, Role: role}, true
+}
+
+// RequireRole allows the request only when the user has one of the roles.
+// Must be registered after AuthMiddleware.
+func RequireRole(roles ...string) fiber.Handler {
+	mustBeValidRoles(roles)
+
+	return func(c *fiber.Ctx) error {
+		actor, ok := CurrentActor(c)
+		if !ok {
+			return response.Unauthorized(c, "User not authenticated")
+		}
+
+		if !actor.HasRole(roles...) {
+			return response.Forbidden(c, policy.ErrForbidden.Message)
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireSelfOrRole allows the request when the route param identifies the
+// caller's own record, or when the user has one of the roles.
+// Must be registered after AuthMiddleware.
+func RequireSelfOrRole(param string, roles ...string) fiber.Handler {
+	mustBeValidRoles(roles)
+
+	return func(c *fiber.Ctx) error {
+		actor, ok := CurrentActor(c)
+		if !ok {
+			return response.Unauthorized(c, "User not authenticated")
+		}
+
+		if actor.HasRole(roles...) {
+			return c.Next()
+		}
+
+		id, err := strconv.ParseUint(c.Params(param), 10, 64)
+		if err != nil || !actor.IsSelf(uint(id)) {
+			return response.Forbidden(c, policy.ErrForbidden.Message)
+		}
+
+		return c.Next()
+	}
+}
+
+// mustBeValidRoles catches typos in route setup at startup
+func mustBeValidRoles(roles []string) {
+	for _, role := range roles {
+		if !models.IsValidRole(role) {
+			panic("middleware: unknown role " + role)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	pkgLogger "github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/logger"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/tracing"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a server span per request, continuing the trace from an
+// incoming W3C traceparent header. The span is named after the route template
+// and its trace ID is attached to the request scoped logger.
+func Tracing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), requestHeaderCarrier{c})
+		ctx, span := tracing.Start(ctx, c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.URLPath(c.Path()),
+				semconv.ClientAddress(c.IP()),
+			),
+		)
+		defer span.End()
+
+		if spanContext := span.SpanContext(); spanContext.IsValid() {
+			ctx = pkgLogger.WithFields(ctx, pkgLogger.Fields{"trace_id": spanContext.TraceID().String()})
+		}
+		c.SetUserContext(ctx)
+
+		err := nextWithErrorHandler(c)
+
+		route := c.Route().Path
+		status := c.Response().StatusCode()
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(status))
+
+		// client errors are not failures of the server span
+		if status >= fiber.StatusInternalServerError {
+			if err == nil {
+				err = fiber.NewError(status)
+			}
+			tracing.RecordError(span, err)
+		}
+
+		return nil
+	}
+}
+
+// requestHeaderCarrier reads propagation headers from the fasthttp request
+type requestHeaderCarrier struct {
+	c *fiber.Ctx
+}
+
+func (r requestHeaderCarrier) Get(key string) string {
+	return r.c.Get(key)
+}
+
+func (r requestHeaderCarrier) Set(key, value string) {
+	r.c.Request().Header.Set(key, value)
+}
+
+func (r requestHeaderCarrier) Keys() []string {
+	keys := make([]string, 0)
+	r.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// RefreshToken stores a hashed, single-use refresh token. Tokens issued from
+// the same login share a FamilyID so the whole chain can be revoked at once.
+type RefreshToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	User       *User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	TokenHash  string     `gorm:"uniqueIndex;not null" json:"-"`
+	FamilyID   string     `gorm:"index;not null" json:"family_id"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ReplacedBy *uint      `json:"replaced_by,omitempty"`
+	CreatedAt  time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// IsRevoked reports whether the token has already been used or revoked
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// IsExpired reports whether the token is past its expiry
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// TwoFactorRecoveryCode stores a hashed, single-use code that replaces a TOTP
+// code when the user has lost their authenticator
+type TwoFactorRecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
@@ -14,4 +14,23 @@ type User struct {
 	CreatedAt time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// EmailVerifiedAt is nil until the user confirms their email address
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+	// TOTPSecret is encrypted and set at enrollment, TOTPEnabledAt once the first code is confirmed.
+	// TOTPLastStep is the time step of the last accepted code, so a code cannot be used twice.
+	TOTPSecret    string     `gorm:"column:totp_secret" json:"-"`
+	TOTPEnabledAt *time.Time `gorm:"column:totp_enabled_at" json:"-"`
+	TOTPLastStep  *int64     `gorm:"column:totp_last_step" json:"-"`
+}
+
+// IsTwoFactorEnabled reports whether login requires a TOTP code after the password
+func (u *User) IsTwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+// IsEmailVerified reports whether the user has confirmed their email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
@@ -0,0 +1,21 @@
+package policy
+
+import "github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
+
+// CanMutateBook only the owner or an admin can update or delete a book
+func CanMutateBook(actor Actor, book *models.Book) error {
+	if actor.IsAdmin() || actor.IsSelf(book.UserID) {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// BookOwnerScope returns the owner id repository writes must be restricted
+// to, or nil when the actor may write any book
+func BookOwnerScope(actor Actor) *uint {
+	if actor.IsAdmin() {
+		return nil
+	}
+	userID := actor.UserID
+	return &userID
+}
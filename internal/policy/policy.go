@@ -0,0 +1,35 @@
+package policy
+
+import (
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/apperror"
+)
+
+// ErrForbidden is returned when the actor is authenticated but not allowed to act
+var ErrForbidden = apperror.Forbidden("FORBIDDEN", "you are not allowed to perform this action")
+
+// Actor is the authenticated user performing a request
+type Actor struct {
+	UserID uint
+	Role   string
+}
+
+// IsAdmin reports whether the actor has the ADMIN role
+func (a Actor) IsAdmin() bool {
+	return a.Role == models.RoleAdmin
+}
+
+// HasRole reports whether the actor has one of the given roles
+func (a Actor) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if a.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSelf reports whether the actor is the given user
+func (a Actor) IsSelf(userID uint) bool {
+	return a.UserID == userID
+}
@@ -0,0 +1,47 @@
+package policy
+
+// CanListUsers only admins can browse all users
+func CanListUsers(actor Actor) error {
+	if actor.IsAdmin() {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// CanViewUser admins can view anyone, users only themselves
+func CanViewUser(actor Actor, userID uint) error {
+	if actor.IsAdmin() || actor.IsSelf(userID) {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// CanUpdateUser admins can update anyone, users only themselves
+func CanUpdateUser(actor Actor, userID uint) error {
+	return CanViewUser(actor, userID)
+}
+
+// CanChangeRole only admins can assign roles
+func CanChangeRole(actor Actor) error {
+	if actor.IsAdmin() {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// CanSetPassword only admins can set another user's password. Users change
+// their own through the change-password endpoint, which checks the current one.
+func CanSetPassword(actor Actor, userID uint) error {
+	if actor.IsAdmin() && !actor.IsSelf(userID) {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// CanDeleteUser only admins can delete users
+func CanDeleteUser(actor Actor) error {
+	if actor.IsAdmin() {
+		return nil
+	}
+	return ErrForbidden
+}
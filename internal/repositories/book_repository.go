@@ -1,53 +1,89 @@
 package repositories
 
 import (
+	"context"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/database"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/utils"
+	"gorm.io/gorm"
 )
 
-type BookRepository struct{}
+type BookRepository struct {
+	db *gorm.DB
+}
 
-func NewBookRepository() *BookRepository {
-	return &BookRepository{}
+func NewBookRepository(db *gorm.DB) *BookRepository {
+	return &BookRepository{db: db}
 }
 
-func (r *BookRepository) Create(book *models.Book) error {
-	return database.DB.Create(&book).Error
+func (r *BookRepository) Create(ctx context.Context, book *models.Book) error {
+	return translateError(database.Conn(ctx, r.db).Create(&book).Error, "book")
 }
 
-func (r *BookRepository) GetAll(params *utils.PaginationParams) ([]*models.Book, int64, error) {
+func (r *BookRepository) GetAll(ctx context.Context, params *utils.PaginationParams) ([]*models.Book, int64, error) {
 	var books []*models.Book
 	var total int64
-	query := database.DB.Model(&models.Book{})
-
-	// Search functionality
-	if params.Search != "" {
-		query = query.Where("title ILIKE ? or author ILIKE ?", "%"+params.Search+"%", "%"+params.Search+"%")
-	}
+	query := r.listQuery(ctx, params)
 
 	// Count total
-	query.Count(&total)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, translateError(err, "book")
+	}
 
 	// Apply pagination and sorting
-	err := query.Preload("User").Order(params.Sort + " " + params.Order).
+	err := applySorts(query.Preload("User"), params.Sorts).
 		Offset(params.GetOffset()).
 		Limit(params.Size).
 		Find(&books).Error
 
-	return books, total, err
+	return books, total, translateError(err, "book")
+}
+
+// GetAllKeyset returns a page of books after or before params.Cursor without counting
+func (r *BookRepository) GetAllKeyset(ctx context.Context, params *utils.PaginationParams) ([]*models.Book, *utils.CursorPage, error) {
+	books, page, err := findKeyset[models.Book](r.listQuery(ctx, params).Preload("User"), params)
+	return books, page, translateError(err, "book")
 }
 
-func (r *BookRepository) GetById(id uint) (*models.Book, error) {
+func (r *BookRepository) GetById(ctx context.Context, id uint) (*models.Book, error) {
 	var book models.Book
-	err := database.DB.Preload("User").Where("id = ?", id).First(&book).Error
-	return &book, err
+	err := database.Conn(ctx, r.db).Preload("User").Where("id = ?", id).First(&book).Error
+	return &book, translateError(err, "book")
+}
+
+// Update writes the book, restricted to ownerID when it is not nil
+func (r *BookRepository) Update(ctx context.Context, id uint, book *models.Book, ownerID *uint) error {
+	query := scopeToOwner(database.Conn(ctx, r.db).Model(&models.Book{}).Where("id = ?", id), ownerID)
+	return translateError(affectedOrNotFound(query.Updates(&book)), "book")
 }
 
-func (r *BookRepository) Update(id uint, book *models.Book) error {
-	return database.DB.Model(&models.Book{}).Where("id = ?", id).Updates(&book).Error
+// Delete removes the book, restricted to ownerID when it is not nil
+func (r *BookRepository) Delete(ctx context.Context, id uint, ownerID *uint) error {
+	query := scopeToOwner(database.Conn(ctx, r.db).Where("id = ?", id), ownerID)
+	return translateError(affectedOrNotFound(query.Delete(&models.Book{})), "book")
 }
 
-func (r *BookRepository) Delete(id uint) error {
-	return database.DB.Where("id = ?", id).Delete(&models.Book{}).Error
+// DeleteByUserID removes every book owned by the user
+func (r *BookRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return translateError(database.Conn(ctx, r.db).Where("user_id = ?", userID).Delete(&models.Book{}).Error, "book")
+}
+
+// listQuery applies search and whitelisted filters shared by both pagination modes
+func (r *BookRepository) listQuery(ctx context.Context, params *utils.PaginationParams) *gorm.DB {
+	query := database.Conn(ctx, r.db).Model(&models.Book{})
+
+	// Search functionality
+	if params.Search != "" {
+		query = query.Where("title ILIKE ? or author ILIKE ?", "%"+params.Search+"%", "%"+params.Search+"%")
+	}
+
+	// Whitelisted filters
+	return applyFilters(query, params.Filters)
+}
+
+func scopeToOwner(query *gorm.DB, ownerID *uint) *gorm.DB {
+	if ownerID != nil {
+		query = query.Where("user_id = ?", *ownerID)
+	}
+	return query
 }
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"errors"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/apperror"
+	"gorm.io/gorm"
+	"strings"
+)
+
+// translateError converts GORM errors into domain errors for the named resource.
+// Duplicate keys are only reported when gorm.Config.TranslateError is enabled.
+func translateError(err error, resource string) error {
+	if err == nil {
+		return nil
+	}
+
+	code := strings.ToUpper(strings.ReplaceAll(resource, " ", "_"))
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return apperror.NotFound(code+"_NOT_FOUND", resource+" not found")
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return apperror.Conflict(code+"_ALREADY_EXISTS", resource+" already exists")
+	default:
+		return apperror.Internal(err)
+	}
+}
+
+// affectedOrNotFound reports a write that matched no rows as not found
+func affectedOrNotFound(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/database"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+type PasswordResetTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetTokenRepository(db *gorm.DB) *PasswordResetTokenRepository {
+	return &PasswordResetTokenRepository{db: db}
+}
+
+func (r *PasswordResetTokenRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	return translateError(database.Conn(ctx, r.db).Create(token).Error, "password reset token")
+}
+
+func (r *PasswordResetTokenRepository) GetByHash(ctx context.Context, hash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := database.Conn(ctx, r.db).Where("token_hash = ?", hash).First(&token).Error
+	return &token, translateError(err, "password reset token")
+}
+
+// MarkUsed consumes a token. It returns false when the token was already used,
+// so two concurrent resets with the same token cannot both succeed.
+func (r *PasswordResetTokenRepository) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	result := database.Conn(ctx, r.db).Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, translateError(result.Error, "password reset token")
+}
+
+// InvalidateForUser consumes every outstanding token of the user
+func (r *PasswordResetTokenRepository) InvalidateForUser(ctx context.Context, userID uint) error {
+	err := database.Conn(ctx, r.db).Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+	return translateError(err, "password reset token")
+}
+
+// DeleteExpired removes tokens that expired before the given time
+func (r *PasswordResetTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := database.Conn(ctx, r.db).Where("expires_at < ?", before).Delete(&models.PasswordResetToken{})
+	return result.RowsAffected, translateError(result.Error, "password reset token")
+}
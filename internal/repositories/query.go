@@ -0,0 +1,152 @@
+package repositories
+
+import (
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+	"strings"
+)
+
+// applyFilters adds the whitelisted filters as parameterized WHERE conditions.
+// Column names come from the QuerySpec, never from the request.
+func applyFilters(query *gorm.DB, filters []utils.Filter) *gorm.DB {
+	for _, filter := range filters {
+		if filter.Op == utils.OpIn {
+			query = query.Where(clause.IN{Column: clause.Column{Name: filter.Column}, Values: filter.Value.([]interface{})})
+			continue
+		}
+		sql := "? " + filter.Op.SQL() + " ?"
+		if filter.Op == utils.OpLike {
+			// parseFilter escapes wildcards in like values with a backslash
+			sql += ` ESCAPE '\'`
+		}
+		query = query.Where(clause.Expr{
+			SQL:  sql,
+			Vars: []interface{}{clause.Column{Name: filter.Column}, filter.Value},
+		})
+	}
+	return query
+}
+
+// applySorts adds the whitelisted sort columns
+func applySorts(query *gorm.DB, sorts []utils.SortField) *gorm.DB {
+	for _, sort := range sorts {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: sort.Column}, Desc: sort.Desc})
+	}
+	return query
+}
+
+// findKeyset runs a keyset paginated query. It fetches one extra row to
+// know whether another page exists, and reads backwards from the cursor
+// for prev cursors so no offset or count query is needed.
+func findKeyset[T any](query *gorm.DB, params *utils.PaginationParams) ([]*T, *utils.CursorPage, error) {
+	sorts := params.Sorts
+	backward := params.Cursor != nil && params.Cursor.Before
+	if backward {
+		sorts = reverseSorts(sorts)
+	}
+
+	// a cursor without values reads from the end of the list
+	positioned := params.Cursor != nil && len(params.Cursor.Values) > 0
+	if positioned {
+		query = query.Where(keysetCondition(sorts, params.Cursor.Values))
+	}
+
+	var rows []*T
+	if err := applySorts(query, sorts).Limit(params.Size + 1).Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	hasMore := len(rows) > params.Size
+	if hasMore {
+		rows = rows[:params.Size]
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	page := &utils.CursorPage{}
+	if len(rows) == 0 {
+		// paged past the end, the way back starts from the end of the list
+		// since the cursor row itself may be gone
+		if positioned {
+			edge := utils.EncodeCursor(utils.Cursor{Sort: utils.SortKey(params.Sorts), Before: !backward})
+			if backward {
+				page.NextCursor = edge
+			} else {
+				page.PrevCursor = edge
+			}
+		}
+		return rows, page, nil
+	}
+
+	// forward: more rows means a next page, a positioned cursor means a previous one
+	// backward: the other way around
+	hasNext, hasPrev := hasMore, positioned
+	if backward {
+		hasNext, hasPrev = positioned, hasMore
+	}
+
+	if err := query.Statement.Parse(new(T)); err != nil {
+		return nil, nil, err
+	}
+	if hasNext {
+		page.NextCursor = cursorFor(query, params.Sorts, rows[len(rows)-1], false)
+	}
+	if hasPrev {
+		page.PrevCursor = cursorFor(query, params.Sorts, rows[0], true)
+	}
+
+	return rows, page, nil
+}
+
+// keysetCondition builds (a > ?) OR (a = ? AND b > ?) ... for the sort columns,
+// using < for descending columns
+func keysetCondition(sorts []utils.SortField, values []interface{}) clause.Expr {
+	var terms []string
+	var vars []interface{}
+
+	for i := range sorts {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, "? = ?")
+			vars = append(vars, clause.Column{Name: sorts[j].Column}, values[j])
+		}
+
+		op := ">"
+		if sorts[i].Desc {
+			op = "<"
+		}
+		parts = append(parts, "? "+op+" ?")
+		vars = append(vars, clause.Column{Name: sorts[i].Column}, values[i])
+
+		terms = append(terms, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return clause.Expr{SQL: "(" + strings.Join(terms, " OR ") + ")", Vars: vars}
+}
+
+// cursorFor encodes the sort column values of row as a cursor
+func cursorFor(query *gorm.DB, sorts []utils.SortField, row interface{}, before bool) string {
+	value := reflect.Indirect(reflect.ValueOf(row))
+	values := make([]interface{}, len(sorts))
+	for i, sort := range sorts {
+		if field := query.Statement.Schema.LookUpField(sort.Column); field != nil {
+			values[i], _ = field.ValueOf(query.Statement.Context, value)
+		}
+	}
+
+	return utils.EncodeCursor(utils.Cursor{Sort: utils.SortKey(sorts), Values: values, Before: before})
+}
+
+func reverseSorts(sorts []utils.SortField) []utils.SortField {
+	reversed := make([]utils.SortField, len(sorts))
+	for i, sort := range sorts {
+		sort.Desc = !sort.Desc
+		reversed[i] = sort
+	}
+	return reversed
+}
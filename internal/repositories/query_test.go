@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/utils"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"reflect"
+	"testing"
+)
+
+// newDryRunDB builds SQL without a database connection
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestApplyFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		filters  []utils.Filter
+		wantSQL  string
+		wantVars []interface{}
+	}{
+		{
+			name:     "comparison",
+			filters:  []utils.Filter{{Column: "user_id", Op: utils.OpGte, Value: int64(3)}},
+			wantSQL:  `SELECT * FROM "books" WHERE "user_id" >= $1 AND "books"."deleted_at" IS NULL`,
+			wantVars: []interface{}{int64(3)},
+		},
+		{
+			name:     "like names the escape character",
+			filters:  []utils.Filter{{Column: "title", Op: utils.OpLike, Value: `%100\%%`}},
+			wantSQL:  `SELECT * FROM "books" WHERE "title" ILIKE $1 ESCAPE '\' AND "books"."deleted_at" IS NULL`,
+			wantVars: []interface{}{`%100\%%`},
+		},
+		{
+			name:     "in binds every value",
+			filters:  []utils.Filter{{Column: "user_id", Op: utils.OpIn, Value: []interface{}{int64(1), int64(2)}}},
+			wantSQL:  `SELECT * FROM "books" WHERE "user_id" IN ($1,$2) AND "books"."deleted_at" IS NULL`,
+			wantVars: []interface{}{int64(1), int64(2)},
+		},
+		{
+			name: "filters are combined with and",
+			filters: []utils.Filter{
+				{Column: "title", Op: utils.OpNe, Value: "Dune"},
+				{Column: "user_id", Op: utils.OpEq, Value: int64(7)},
+			},
+			wantSQL:  `SELECT * FROM "books" WHERE "title" <> $1 AND "user_id" = $2 AND "books"."deleted_at" IS NULL`,
+			wantVars: []interface{}{"Dune", int64(7)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var books []models.Book
+			stmt := applyFilters(newDryRunDB(t).Model(&models.Book{}), tt.filters).Find(&books).Statement
+
+			if got := stmt.SQL.String(); got != tt.wantSQL {
+				t.Errorf("SQL = %s, want %s", got, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(stmt.Vars, tt.wantVars) {
+				t.Errorf("Vars = %#v, want %#v", stmt.Vars, tt.wantVars)
+			}
+		})
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	sorts := []utils.SortField{
+		{Field: "created_at", Column: "created_at", Desc: true},
+		{Field: "title", Column: "title"},
+		{Field: "id", Column: "id"},
+	}
+
+	var books []models.Book
+	stmt := newDryRunDB(t).Model(&models.Book{}).Where(keysetCondition(sorts, []interface{}{"2024-01-02", "Dune", int64(42)})).Find(&books).Statement
+
+	wantSQL := `SELECT * FROM "books" WHERE ((("created_at" < $1) OR ("created_at" = $2 AND "title" > $3) OR ("created_at" = $4 AND "title" = $5 AND "id" > $6))) AND "books"."deleted_at" IS NULL`
+	if got := stmt.SQL.String(); got != wantSQL {
+		t.Errorf("SQL = %s, want %s", got, wantSQL)
+	}
+	wantVars := []interface{}{"2024-01-02", "2024-01-02", "Dune", "2024-01-02", "Dune", int64(42)}
+	if !reflect.DeepEqual(stmt.Vars, wantVars) {
+		t.Errorf("Vars = %#v, want %#v", stmt.Vars, wantVars)
+	}
+}
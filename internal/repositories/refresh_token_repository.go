@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/database"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+type RefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	return translateError(database.Conn(ctx, r.db).Create(token).Error, "refresh token")
+}
+
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := database.Conn(ctx, r.db).Preload("User").Where("token_hash = ?", hash).First(&token).Error
+	return &token, translateError(err, "refresh token")
+}
+
+// Revoke marks a token as used. It returns false when the token was already
+// revoked, so concurrent refreshes with the same token cannot both succeed.
+func (r *RefreshTokenRepository) Revoke(ctx context.Context, id uint, replacedBy *uint) (bool, error) {
+	result := database.Conn(ctx, r.db).Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "replaced_by": replacedBy})
+	return result.RowsAffected > 0, translateError(result.Error, "refresh token")
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	err := database.Conn(ctx, r.db).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+	return translateError(err, "refresh token")
+}
+
+func (r *RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uint) error {
+	err := database.Conn(ctx, r.db).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+	return translateError(err, "refresh token")
+}
+
+// DeleteExpired removes tokens that expired before the given time
+func (r *RefreshTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := database.Conn(ctx, r.db).Where("expires_at < ?", before).Delete(&models.RefreshToken{})
+	return result.RowsAffected, translateError(result.Error, "refresh token")
+}
@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/database"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+type TwoFactorRecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRecoveryCodeRepository(db *gorm.DB) *TwoFactorRecoveryCodeRepository {
+	return &TwoFactorRecoveryCodeRepository{db: db}
+}
+
+// ReplaceForUser deletes the user's codes and stores the given hashes,
+// callers run it in a transaction so a failure keeps the old codes
+func (r *TwoFactorRecoveryCodeRepository) ReplaceForUser(ctx context.Context, userID uint, hashes []string) error {
+	conn := database.Conn(ctx, r.db)
+	if err := conn.Where("user_id = ?", userID).Delete(&models.TwoFactorRecoveryCode{}).Error; err != nil {
+		return translateError(err, "recovery code")
+	}
+
+	codes := make([]models.TwoFactorRecoveryCode, len(hashes))
+	for i, hash := range hashes {
+		codes[i] = models.TwoFactorRecoveryCode{UserID: userID, CodeHash: hash}
+	}
+	return translateError(conn.Create(&codes).Error, "recovery code")
+}
+
+// Use consumes an unused code of the user. It returns false when no such code
+// exists, so a code cannot be used twice even by concurrent logins.
+func (r *TwoFactorRecoveryCodeRepository) Use(ctx context.Context, userID uint, hash string) (bool, error) {
+	result := database.Conn(ctx, r.db).Model(&models.TwoFactorRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, translateError(result.Error, "recovery code")
+}
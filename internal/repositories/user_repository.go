@@ -1,60 +1,125 @@
 package repositories
 
 import (
+	"context"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/database"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/utils"
+	"gorm.io/gorm"
+	"time"
 )
 
-type UserRepository struct{}
+type UserRepository struct {
+	db *gorm.DB
+}
 
-func NewUserRepository() *UserRepository {
-	return &UserRepository{}
+func NewUserRepository(db *gorm.DB) *UserRepository {
+	return &UserRepository{db: db}
 }
 
-func (r *UserRepository) Create(user *models.User) error {
-	return database.DB.Create(user).Error
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	return translateError(database.Conn(ctx, r.db).Create(user).Error, "user")
 }
 
-func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := database.DB.Where("email = ?", email).First(&user).Error
-	return &user, err
+	err := database.Conn(ctx, r.db).Where("email = ?", email).First(&user).Error
+	return &user, translateError(err, "user")
 }
 
-func (r *UserRepository) GetByID(id uint) (*models.User, error) {
+func (r *UserRepository) GetByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	err := database.DB.Where("id = ?", id).First(&user).Error
-	return &user, err
+	err := database.Conn(ctx, r.db).Where("id = ?", id).First(&user).Error
+	return &user, translateError(err, "user")
+}
+
+func (r *UserRepository) Update(ctx context.Context, id uint, user *models.User) error {
+	return translateError(database.Conn(ctx, r.db).Model(user).Where("id = ?", id).Updates(user).Error, "user")
+}
+
+// MarkEmailVerified sets email_verified_at for an unverified user. It returns
+// false when the user was already verified, so a token cannot be used twice.
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id uint, at time.Time) (bool, error) {
+	result := database.Conn(ctx, r.db).Model(&models.User{}).
+		Where("id = ? AND email_verified_at IS NULL", id).
+		Update("email_verified_at", at)
+	return result.RowsAffected > 0, translateError(result.Error, "user")
+}
+
+// ClearEmailVerified marks the user's email as unverified again, e.g. after it changed
+func (r *UserRepository) ClearEmailVerified(ctx context.Context, id uint) error {
+	result := database.Conn(ctx, r.db).Model(&models.User{}).
+		Where("id = ?", id).
+		Update("email_verified_at", nil)
+	return translateError(result.Error, "user")
 }
 
-func (r *UserRepository) Update(id uint, user *models.User) error {
-	return database.DB.Model(user).Where("id = ?", id).Updates(user).Error
+// SetTOTPSecret stores a new pending TOTP secret. It returns false when two-factor
+// authentication is already enabled, an active secret is never replaced.
+func (r *UserRepository) SetTOTPSecret(ctx context.Context, id uint, secret string) (bool, error) {
+	result := database.Conn(ctx, r.db).Model(&models.User{}).
+		Where("id = ? AND totp_enabled_at IS NULL", id).
+		Updates(map[string]interface{}{"totp_secret": secret, "totp_last_step": nil})
+	return result.RowsAffected > 0, translateError(result.Error, "user")
 }
 
-func (r *UserRepository) Delete(id uint) error {
-	return database.DB.Delete(&models.User{}, id).Error
+// EnableTOTP turns on two-factor authentication for a user with a pending secret.
+// It returns false when it was already enabled.
+func (r *UserRepository) EnableTOTP(ctx context.Context, id uint, at time.Time) (bool, error) {
+	result := database.Conn(ctx, r.db).Model(&models.User{}).
+		Where("id = ? AND totp_enabled_at IS NULL AND totp_secret IS NOT NULL", id).
+		Update("totp_enabled_at", at)
+	return result.RowsAffected > 0, translateError(result.Error, "user")
 }
 
-func (r *UserRepository) GetAll(params *utils.PaginationParams) ([]*models.User, int64, error) {
+// AdvanceTOTPStep records the time step of an accepted code. It returns false
+// when that step or a later one was already used, so a code works only once.
+func (r *UserRepository) AdvanceTOTPStep(ctx context.Context, id uint, step int64) (bool, error) {
+	result := database.Conn(ctx, r.db).Model(&models.User{}).
+		Where("id = ? AND (totp_last_step IS NULL OR totp_last_step < ?)", id, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected > 0, translateError(result.Error, "user")
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id uint) error {
+	return translateError(affectedOrNotFound(database.Conn(ctx, r.db).Delete(&models.User{}, id)), "user")
+}
+
+func (r *UserRepository) GetAll(ctx context.Context, params *utils.PaginationParams) ([]*models.User, int64, error) {
 	var users []*models.User
 	var total int64
 
-	query := database.DB.Model(&models.User{})
-
-	// Search functionality
-	if params.Search != "" {
-		query = query.Where("name ILIKE ? OR email ILIKE ?", "%"+params.Search+"%", "%"+params.Search+"%")
-	}
+	query := r.listQuery(ctx, params)
 
 	// Count total
-	query.Count(&total)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, translateError(err, "user")
+	}
 
 	// Apply pagination and sorting
-	err := query.Order(params.Sort + " " + params.Order).
+	err := applySorts(query, params.Sorts).
 		Offset(params.GetOffset()).
 		Limit(params.Size).
 		Find(&users).Error
 
-	return users, total, err
+	return users, total, translateError(err, "user")
+}
+
+// GetAllKeyset returns a page of users after or before params.Cursor without counting
+func (r *UserRepository) GetAllKeyset(ctx context.Context, params *utils.PaginationParams) ([]*models.User, *utils.CursorPage, error) {
+	users, page, err := findKeyset[models.User](r.listQuery(ctx, params), params)
+	return users, page, translateError(err, "user")
+}
+
+// listQuery applies search and whitelisted filters shared by both pagination modes
+func (r *UserRepository) listQuery(ctx context.Context, params *utils.PaginationParams) *gorm.DB {
+	query := database.Conn(ctx, r.db).Model(&models.User{})
+
+	// Search functionality
+	if params.Search != "" {
+		query = query.Where("name ILIKE ? OR email ILIKE ?", "%"+params.Search+"%", "%"+params.Search+"%")
+	}
+
+	// Whitelisted filters
+	return applyFilters(query, params.Filters)
 }
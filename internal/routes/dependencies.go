@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/cache"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/health"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/jwt"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/mailer"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/metrics"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/ratelimit"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/revocation"
+	"gorm.io/gorm"
+)
+
+// Dependencies holds the infrastructure created in main and shared by routes and handlers
+type Dependencies struct {
+	DB *gorm.DB
+
+	// Cache may be nil to disable caching
+	Cache     cache.Store
+	RateLimit ratelimit.Store
+	Health    *health.Registry
+	Metrics   *metrics.Metrics
+	Mailer    mailer.Mailer
+
+	// Keys signs and verifies access tokens, Denylist revokes them before they expire
+	Keys     *jwt.KeyRing
+	Denylist *revocation.Denylist
+}
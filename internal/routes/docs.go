@@ -0,0 +1,97 @@
+package routes
+
+import (
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/config"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/schemas"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/health"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/jwt"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/openapi"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+	swaggerFiles "github.com/swaggo/files/v2"
+	"net/http"
+	"strings"
+)
+
+// swaggerInitializer points the bundled Swagger UI at the generated document
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "/docs/openapi.json",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    layout: "StandaloneLayout"
+  });
+};`
+
+// apiDocs describes the routes registered in SetupRoutes, keyed by openapi.Key.
+// Routes missing here still appear in the document with a generic response.
+func apiDocs() map[string]openapi.Route {
+	return map[string]openapi.Route{
+		// Health
+		openapi.Key(fiber.MethodGet, "/health"):       {Summary: "Basic health check", Tags: []string{"health"}, Raw: true, Response: map[string]string{}},
+		openapi.Key(fiber.MethodGet, "/health/live"):  {Summary: "Liveness probe", Tags: []string{"health"}, Raw: true, Response: map[string]string{}},
+		openapi.Key(fiber.MethodGet, "/health/ready"): {Summary: "Readiness probe", Tags: []string{"health"}, Raw: true, Response: health.Report{}, Errors: []int{fiber.StatusServiceUnavailable}},
+		openapi.Key(fiber.MethodGet, "/metrics"):      {Hidden: true},
+
+		// Keys
+		openapi.Key(fiber.MethodGet, "/.well-known/jwks.json"): {Summary: "Public keys that verify access tokens", Tags: []string{"auth"}, Raw: true, Response: jwt.JWKS{}},
+
+		// Auth
+		openapi.Key(fiber.MethodPost, "/api/v1/auth/register"):            {Summary: "Register a new user and email a verification link", Tags: []string{"auth"}, Request: schemas.RegisterRequest{}, Response: schemas.UserResponse{}, Status: fiber.StatusCreated, Errors: []int{fiber.StatusConflict}},
+		openapi.Key(fiber.MethodPost, "/api/v1/auth/login"):               {Summary: "Log in with email and password, returns a two-factor challenge instead of tokens when 2FA is enabled", Tags: []string{"auth"}, Request: schemas.LoginRequest{}, Response: schemas.LoginResponse{}, Errors: []int{fiber.StatusUnauthorized, fiber.StatusForbidden, fiber.StatusTooManyRequests}},
+		openapi.Key(fiber.MethodPost, "/api/v1/auth/2fa/verify"):          {Summary: "Complete a two-factor login with a TOTP or recovery code", Tags: []string{"auth"}, Request: schemas.TwoFactorVerifyRequest{}, Response: schemas.AuthResponse{}, Errors: []int{fiber.StatusUnauthorized, fiber.StatusTooManyRequests}},
+		openapi.Key(fiber.MethodPost, "/api/v1/auth/2fa/enroll"):          {Summary: "Start two-factor enrollment and get the otpauth URI", Tags: []string{"auth"}, Auth: true, Response: schemas.TwoFactorEnrollResponse{}, Errors: []int{fiber.StatusConflict}},
+		openapi.Key(fiber.MethodPost, "/api/v1/auth/2fa/confirm"):         {Summary: "Enable two-factor authentication and get recovery codes", Tags: []string{"auth"}, Auth: true, Request: schemas.TwoFactorConfirmRequest{}, Response: schemas.TwoFactorRecoveryCodesResponse{}, Errors: []int{fiber.StatusConflict, fiber.StatusTooManyRequests}},
+		openapi.Key(fiber.MethodPost, "/api/v1/auth/refresh"):             {Summary: "Rotate a refresh token", Tags: []string{"auth"}, Request: schemas.RefreshTokenRequest{}, Response: schemas.AuthResponse{}, Errors: []int{fiber.StatusUnauthorized}},
+		openapi.Key(fiber.MethodPost, "/api/v1/auth/logout"):              {Summary: "Revoke a refresh token family", Tags: []string{"auth"}, Request: schemas.LogoutRequest{}, Errors: []int{fiber.StatusUnauthorized}},
+		openapi.Key(fiber.MethodPost, "/api/v1/auth/verify-email"):        {Summary: "Verify an email address", Tags: []string{"auth"}, Request: schemas.VerifyEmailRequest{}, Response: schemas.UserResponse{}, Errors: []int{fiber.StatusConflict}},
+		openapi.Key(fiber.MethodPost, "/api/v1/auth/resend-verification"): {Summary: "Resend the verification email", Tags: []string{"auth"}, Request: schemas.ResendVerificationRequest{}, Errors: []int{fiber.StatusTooManyRequests}},
+		openapi.Key(fiber.MethodPost, "/api/v1/auth/forgot-password"):     {Summary: "Email a password reset link", Tags: []string{"auth"}, Request: schemas.ForgotPasswordRequest{}, Errors: []int{fiber.StatusTooManyRequests}},
+		openapi.Key(fiber.MethodPost, "/api/v1/auth/reset-password"):      {Summary: "Set a new password with a reset token", Tags: []string{"auth"}, Request: schemas.ResetPasswordRequest{}, Errors: []int{fiber.StatusTooManyRequests}},
+		openapi.Key(fiber.MethodPost, "/api/v1/auth/change-password"):     {Summary: "Change the current user's password and sign out other sessions", Tags: []string{"auth"}, Auth: true, Request: schemas.ChangePasswordRequest{}, Response: schemas.AuthResponse{}, Errors: []int{fiber.StatusTooManyRequests}},
+		openapi.Key(fiber.MethodGet, "/api/v1/profile"):                   {Summary: "Get the current user", Tags: []string{"auth"}, Auth: true, Response: schemas.UserResponse{}},
+
+		// Users
+		openapi.Key(fiber.MethodGet, "/api/v1/users"):        {Summary: "List users", Tags: []string{"users"}, Auth: true, Response: schemas.UserResponse{}, Paginated: true, Query: &schemas.UserQuerySpec, Errors: []int{fiber.StatusForbidden}},
+		openapi.Key(fiber.MethodGet, "/api/v1/users/:id"):    {Summary: "Get a user", Tags: []string{"users"}, Auth: true, Response: schemas.UserResponse{}, Errors: []int{fiber.StatusForbidden}},
+		openapi.Key(fiber.MethodPut, "/api/v1/users/:id"):    {Summary: "Update a user, only admins can set the password of another user", Tags: []string{"users"}, Auth: true, Request: schemas.UpdateUserRequest{}, Response: schemas.UserResponse{}, Errors: []int{fiber.StatusForbidden, fiber.StatusConflict}},
+		openapi.Key(fiber.MethodDelete, "/api/v1/users/:id"): {Summary: "Delete a user and their books", Tags: []string{"users"}, Auth: true, Errors: []int{fiber.StatusForbidden}},
+
+		// Books
+		openapi.Key(fiber.MethodGet, "/api/v1/books"):        {Summary: "List books", Tags: []string{"books"}, Auth: true, Response: schemas.BookResponse{}, Paginated: true, Query: &schemas.BookQuerySpec},
+		openapi.Key(fiber.MethodGet, "/api/v1/books/:id"):    {Summary: "Get a book", Tags: []string{"books"}, Auth: true, Response: schemas.BookResponse{}},
+		openapi.Key(fiber.MethodPost, "/api/v1/books"):       {Summary: "Create a book", Tags: []string{"books"}, Auth: true, Request: schemas.CreateBookRequest{}, Response: schemas.BookResponse{}},
+		openapi.Key(fiber.MethodPut, "/api/v1/books/:id"):    {Summary: "Update a book", Tags: []string{"books"}, Auth: true, Request: schemas.UpdateBookRequest{}, Response: schemas.BookResponse{}, Errors: []int{fiber.StatusForbidden}},
+		openapi.Key(fiber.MethodDelete, "/api/v1/books/:id"): {Summary: "Delete a book", Tags: []string{"books"}, Auth: true, Errors: []int{fiber.StatusForbidden}},
+	}
+}
+
+// setupDocs serves the OpenAPI document generated from the routes registered
+// so far and the bundled Swagger UI, so it must be called after all other routes
+func setupDocs(app *fiber.App, cfg *config.Config) {
+	document := openapi.NewGenerator(openapi.Info{
+		Title:   cfg.App.Name,
+		Version: "1.0.0",
+	}).Build(app.GetRoutes(true), apiDocs())
+
+	docs := app.Group("/docs")
+	docs.Get("/openapi.json", func(c *fiber.Ctx) error {
+		return c.JSON(document)
+	})
+	docs.Get("/swagger-initializer.js", func(c *fiber.Ctx) error {
+		c.Type("js")
+		return c.SendString(swaggerInitializer)
+	})
+	app.Get("/docs", func(c *fiber.Ctx) error {
+		// Swagger UI loads its assets relative to /docs/
+		if strings.HasSuffix(c.Path(), "/") {
+			return c.Next()
+		}
+		return c.Redirect("/docs/", fiber.StatusMovedPermanently)
+	})
+	app.Use("/docs", filesystem.New(filesystem.Config{
+		Root:  http.FS(swaggerFiles.FS),
+		Index: "index.html",
+	}))
+}
@@ -2,16 +2,24 @@ package routes
 
 import (
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/config"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/database"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/handlers"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/repositories"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/services"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/passwordpolicy"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/ratelimit"
 )
 
 // Handlers holds all application handlers
 type Handlers struct {
-	Auth *handlers.AuthHandler
-	User *handlers.UserHandler
-	Book *handlers.BookHandler
+	Auth         *handlers.AuthHandler
+	Verification *handlers.VerificationHandler
+	Password     *handlers.PasswordHandler
+	TwoFactor    *handlers.TwoFactorHandler
+	User         *handlers.UserHandler
+	Book         *handlers.BookHandler
+	Health       *handlers.HealthHandler
+	JWKS         *handlers.JWKSHandler
 
 	// Easy to add more handlers:
 	// Order *handlers.OrderHandler
@@ -19,24 +27,60 @@ type Handlers struct {
 }
 
 // NewHandlers creates and initializes all application handlers with their dependencies
-func NewHandlers(cfg *config.Config) *Handlers {
+func NewHandlers(cfg *config.Config, deps *Dependencies) *Handlers {
 	// Initialize repositories (data layer)
-	userRepo := repositories.NewUserRepository()
-	bookRepo := repositories.NewBookRepository()
+	userRepo := repositories.NewUserRepository(deps.DB)
+	bookRepo := repositories.NewBookRepository(deps.DB)
+	refreshTokenRepo := repositories.NewRefreshTokenRepository(deps.DB)
+	resetTokenRepo := repositories.NewPasswordResetTokenRepository(deps.DB)
+	recoveryCodeRepo := repositories.NewTwoFactorRecoveryCodeRepository(deps.DB)
+	transactor := database.NewTxManager(deps.DB)
+
+	// Initialize security helpers
+	loginLockout := ratelimit.NewLockout(
+		deps.RateLimit,
+		cfg.RateLimit.LockoutThreshold,
+		cfg.RateLimit.LockoutBase,
+		cfg.RateLimit.LockoutMax,
+		cfg.RateLimit.LockoutWindow,
+	)
+
+	passwordPolicy := passwordpolicy.Policy{
+		MinLength:     cfg.Password.MinLength,
+		MaxLength:     cfg.Password.MaxLength,
+		RequireUpper:  cfg.Password.RequireUpper,
+		RequireLower:  cfg.Password.RequireLower,
+		RequireDigit:  cfg.Password.RequireDigit,
+		RequireSymbol: cfg.Password.RequireSymbol,
+		RejectCommon:  cfg.Password.RejectCommon,
+	}
 
 	// Initialize services (business layer)
-	authService := services.NewAuthService(userRepo, cfg.JWT.Secret)
-	userService := services.NewUserService(userRepo)
-	bookService := services.NewBookService(bookRepo)
+	verificationService := services.NewEmailVerificationService(userRepo, deps.Mailer, cfg.Verify)
+	twoFactorService := services.NewTwoFactorService(userRepo, recoveryCodeRepo, transactor, cfg.TwoFactor)
+	authService := services.NewAuthService(userRepo, refreshTokenRepo, loginLockout, transactor, deps.Metrics, verificationService, passwordPolicy, twoFactorService, cfg.TwoFactor, deps.Keys, deps.Denylist, cfg.JWT)
+	passwordResetService := services.NewPasswordResetService(userRepo, resetTokenRepo, refreshTokenRepo, transactor, deps.Mailer, passwordPolicy, deps.Denylist, cfg.Reset)
+	bookService := services.NewBookService(bookRepo, deps.Cache, cfg.Cache.TTL)
+	userService := services.NewUserService(userRepo, bookRepo, bookService, refreshTokenRepo, transactor, verificationService, passwordPolicy, deps.Denylist)
 
 	// Initialize handler (presentation layer)
 	authHandler := handlers.NewAuthHandler(authService)
+	verificationHandler := handlers.NewVerificationHandler(verificationService)
+	passwordHandler := handlers.NewPasswordHandler(passwordResetService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
 	userHandler := handlers.NewUserHandler(userService)
 	bookHandler := handlers.NewBookHandler(bookService)
+	healthHandler := handlers.NewHealthHandler(deps.Health)
+	jwksHandler := handlers.NewJWKSHandler(deps.Keys)
 
 	return &Handlers{
-		Auth: authHandler,
-		User: userHandler,
-		Book: bookHandler,
+		Auth:         authHandler,
+		Verification: verificationHandler,
+		Password:     passwordHandler,
+		TwoFactor:    twoFactorHandler,
+		User:         userHandler,
+		Book:         bookHandler,
+		Health:       healthHandler,
+		JWKS:         jwksHandler,
 	}
 }
@@ -3,51 +3,117 @@ package routes
 import (
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/config"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/middleware"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/ratelimit"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 )
 
 // SetupRoutes initializes handlers and configures all routes
-func SetupRoutes(app *fiber.App, cfg *config.Config) {
+func SetupRoutes(app *fiber.App, cfg *config.Config, deps *Dependencies) {
 	// Initialize all handlers here
-	h := NewHandlers(cfg)
+	h := NewHandlers(cfg, deps)
 
 	// Health check
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "ok", "message": "Server is running"})
 	})
+	app.Get("/health/live", h.Health.Live)
+	app.Get("/health/ready", h.Health.Ready)
+
+	// Prometheus scrape endpoint
+	app.Get("/metrics", adaptor.HTTPHandler(deps.Metrics.Handler()))
+
+	// Public keys for verifying access tokens
+	app.Get("/.well-known/jwks.json", h.JWKS.Get)
 
 	// API v1 group
 	api := app.Group("/api/v1")
 
-	// Setup route groups with handlers
-	setupAuthRoutes(api, h, cfg.JWT.Secret)
-	setupUserRoutes(api, h, cfg.JWT.Secret)
-	setupBookRoutes(api, h, cfg.JWT.Secret)
+	// Public routes go first, the auth middleware of the protected group runs
+	// for every route under /api/v1 registered after it
+	setupAuthRoutes(api, h, cfg, deps)
+
+	// One auth middleware for all protected routes, so each request verifies its token once
+	protected := api.Group("/", middleware.AuthMiddleware(deps.Keys, deps.Denylist))
+	setupAccountRoutes(protected, h, cfg, deps)
+	setupUserRoutes(protected, h)
+	setupBookRoutes(protected, h)
+
+	// API docs, generated from the routes above
+	setupDocs(app, cfg)
 }
 
-// setupAuthRoutes configures authentication routes
-func setupAuthRoutes(api fiber.Router, h *Handlers, jwtSecret string) {
+// setupAuthRoutes configures the public authentication routes
+// Login and two-factor codes are rate limited per client IP, login also per account email, verification and
+// reset emails to one address are throttled to one per configured interval
+func setupAuthRoutes(api fiber.Router, h *Handlers, cfg *config.Config, deps *Dependencies) {
+	loginPerIP := ratelimit.NewLimiter(deps.RateLimit, cfg.RateLimit.LoginPerIP, cfg.RateLimit.Window)
+	loginPerAccount := ratelimit.NewLimiter(deps.RateLimit, cfg.RateLimit.LoginPerAccount, cfg.RateLimit.Window)
+	resendPerAccount := ratelimit.NewLimiter(deps.RateLimit, 1, cfg.Verify.ResendInterval)
+	resetPerAccount := ratelimit.NewLimiter(deps.RateLimit, 1, cfg.Reset.RequestInterval)
+
 	auth := api.Group("/auth")
 	auth.Post("/register", h.Auth.Register)
-	auth.Post("/login", h.Auth.Login)
+	auth.Post("/login",
+		middleware.RateLimit("login", loginPerIP, middleware.KeyByIP),
+		middleware.RateLimit("login", loginPerAccount, middleware.KeyByBodyField("email")),
+		h.Auth.Login,
+	)
+	auth.Post("/2fa/verify",
+		middleware.RateLimit("2fa-verify", loginPerIP, middleware.KeyByIP),
+		h.Auth.VerifyTwoFactor,
+	)
+	auth.Post("/refresh", h.Auth.Refresh)
+	auth.Post("/logout", h.Auth.Logout)
+	auth.Post("/verify-email", h.Verification.VerifyEmail)
+	auth.Post("/resend-verification",
+		middleware.RateLimit("verify-resend", loginPerIP, middleware.KeyByIP),
+		middleware.RateLimit("verify-resend", resendPerAccount, middleware.KeyByBodyField("email")),
+		h.Verification.Resend,
+	)
+	auth.Post("/forgot-password",
+		middleware.RateLimit("forgot-password", loginPerIP, middleware.KeyByIP),
+		middleware.RateLimit("forgot-password", resetPerAccount, middleware.KeyByBodyField("email")),
+		h.Password.ForgotPassword,
+	)
+	auth.Post("/reset-password",
+		middleware.RateLimit("reset-password", loginPerIP, middleware.KeyByIP),
+		h.Password.ResetPassword,
+	)
+}
+
+// setupAccountRoutes configures the authenticated user's own account routes
+// Password changes and two-factor confirmation are rate limited per client IP like login
+func setupAccountRoutes(protected fiber.Router, h *Handlers, cfg *config.Config, deps *Dependencies) {
+	loginPerIP := ratelimit.NewLimiter(deps.RateLimit, cfg.RateLimit.LoginPerIP, cfg.RateLimit.Window)
 
-	// Protected auth routes
-	protected := api.Group("/", middleware.AuthMiddleware(jwtSecret))
 	protected.Get("/profile", h.Auth.GetProfile)
+	protected.Post("/auth/change-password",
+		middleware.RateLimit("change-password", loginPerIP, middleware.KeyByIP),
+		h.Auth.ChangePassword,
+	)
+	protected.Post("/auth/2fa/enroll", h.TwoFactor.Enroll)
+	protected.Post("/auth/2fa/confirm",
+		middleware.RateLimit("2fa-confirm", loginPerIP, middleware.KeyByIP),
+		h.TwoFactor.Confirm,
+	)
 }
 
 // setupUserRoutes configures user routes
-func setupUserRoutes(api fiber.Router, h *Handlers, jwtSecret string) {
-	protected := api.Group("/", middleware.AuthMiddleware(jwtSecret))
-	protected.Get("/users", h.User.GetAll)
-	protected.Get("/users/:id", h.User.GetByID)
-	protected.Put("/users/:id", h.User.Update)
-	protected.Delete("/users/:id", h.User.Delete)
+// Listing and deleting users is admin-only, reading and updating is limited to the caller's own record unless admin
+func setupUserRoutes(protected fiber.Router, h *Handlers) {
+	adminOnly := middleware.RequireRole(models.RoleAdmin)
+	selfOrAdmin := middleware.RequireSelfOrRole("id", models.RoleAdmin)
+
+	protected.Get("/users", adminOnly, h.User.GetAll)
+	protected.Get("/users/:id", selfOrAdmin, h.User.GetByID)
+	protected.Put("/users/:id", selfOrAdmin, h.User.Update)
+	protected.Delete("/users/:id", adminOnly, h.User.Delete)
 }
 
 // setupBookRoutes configuras book routes
-func setupBookRoutes(api fiber.Router, h *Handlers, jwtSecret string) {
-	protected := api.Group("/", middleware.AuthMiddleware(jwtSecret))
+func setupBookRoutes(protected fiber.Router, h *Handlers) {
 	protected.Get("/books", h.Book.GetAll)
 	protected.Get("/books/:id", h.Book.GetById)
 	protected.Post("/books", h.Book.Create)
@@ -2,6 +2,7 @@ package schemas
 
 import (
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/utils"
 	"time"
 )
 
@@ -17,6 +18,18 @@ type UpdateBookRequest struct {
 	Description string `json:"desc" validate:"omitempty,max=1000"`
 }
 
+// BookQuerySpec whitelists the fields clients can sort and filter books by
+var BookQuerySpec = utils.QuerySpec{
+	Fields: map[string]utils.QueryField{
+		"id":         {Column: "id", Type: utils.FieldNumber, Sortable: true, Filterable: true},
+		"title":      {Column: "title", Type: utils.FieldString, Sortable: true, Filterable: true},
+		"author":     {Column: "author", Type: utils.FieldString, Sortable: true, Filterable: true},
+		"user_id":    {Column: "user_id", Type: utils.FieldNumber, Sortable: true, Filterable: true},
+		"created_at": {Column: "created_at", Type: utils.FieldTime, Sortable: true, Filterable: true},
+		"updated_at": {Column: "updated_at", Type: utils.FieldTime, Sortable: true, Filterable: true},
+	},
+}
+
 type BookResponse struct {
 	ID        uint         `json:"id"`
 	Title     string       `json:"title"`
@@ -2,16 +2,29 @@ package schemas
 
 import (
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/utils"
 	"time"
 )
 
 type UpdateUserRequest struct {
 	Email    string `json:"email" validate:"omitempty,email"`
 	Username string `json:"username" validate:"omitempty,min=2"`
-	Password string `json:"password" validate:"omitempty,min=8"`
+	Password string `json:"password" validate:"omitempty"`
 	Role     string `json:"role" validate:"omitempty,oneof=USER ADMIN"`
 }
 
+// UserQuerySpec whitelists the fields clients can sort and filter users by
+var UserQuerySpec = utils.QuerySpec{
+	Fields: map[string]utils.QueryField{
+		"id":         {Column: "id", Type: utils.FieldNumber, Sortable: true, Filterable: true},
+		"name":       {Column: "name", Type: utils.FieldString, Sortable: true, Filterable: true},
+		"email":      {Column: "email", Type: utils.FieldString, Sortable: true, Filterable: true},
+		"role":       {Column: "role", Type: utils.FieldString, Sortable: true, Filterable: true},
+		"created_at": {Column: "created_at", Type: utils.FieldTime, Sortable: true, Filterable: true},
+		"updated_at": {Column: "updated_at", Type: utils.FieldTime, Sortable: true, Filterable: true},
+	},
+}
+
 type UserResponse struct {
 	ID        uint      `json:"id"`
 	Email     string    `json:"email"`
@@ -19,6 +32,9 @@ type UserResponse struct {
 	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+
+	EmailVerifiedAt  *time.Time `json:"emailVerifiedAt"`
+	TwoFactorEnabled bool       `json:"twoFactorEnabled"`
 }
 
 // Helper function that convert model to response
@@ -31,5 +47,8 @@ func UserToResponse(user *models.User) UserResponse {
 		Role:      user.Role,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
+
+		EmailVerifiedAt:  user.EmailVerifiedAt,
+		TwoFactorEnabled: user.IsTwoFactorEnabled(),
 	}
 }
@@ -1,39 +1,129 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/config"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/schemas"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/apperror"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/jwt"
+	pkgLogger "github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/logger"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/passwordpolicy"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/ratelimit"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/signedtoken"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/tracing"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/utils"
+	"sync"
+	"time"
 )
 
+// RefreshTokenRepositoryInterface defines what AuthService needs to persist refresh tokens
+type RefreshTokenRepositoryInterface interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	Revoke(ctx context.Context, id uint, replacedBy *uint) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID uint) error
+}
+
+// LoginLockoutInterface tracks failed logins per account
+type LoginLockoutInterface interface {
+	Locked(ctx context.Context, key string) (time.Duration, error)
+	Fail(ctx context.Context, key string) (time.Duration, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// AuthMetricsInterface counts authentication outcomes
+type AuthMetricsInterface interface {
+	AuthAttempt(action string, success bool)
+}
+
+// EmailVerifierInterface sends verification emails to new users
+type EmailVerifierInterface interface {
+	SendVerification(ctx context.Context, user *models.User) error
+}
+
+// AccessTokenIssuerInterface signs access tokens
+type AccessTokenIssuerInterface interface {
+	GenerateToken(userID uint, email, role string, ttl time.Duration) (string, time.Time, error)
+}
+
+// TwoFactorVerifierInterface checks the second login factor
+type TwoFactorVerifierInterface interface {
+	VerifyCode(ctx context.Context, user *models.User, code string) error
+}
+
+// twoFactorClaims are signed into the challenge token login returns when a code is still needed
+type twoFactorClaims struct {
+	UserID uint `json:"uid"`
+}
+
 // AuthService handles authentication business logic
 type AuthService struct {
-	userRepo  UserRepositoryInterface
-	jwtSecret string
+	userRepo         UserRepositoryInterface
+	refreshTokenRepo RefreshTokenRepositoryInterface
+	loginLockout     LoginLockoutInterface
+	transactor       TransactorInterface
+	metrics          AuthMetricsInterface
+	verifier         EmailVerifierInterface
+	passwordPolicy   passwordpolicy.Policy
+	twoFactor        TwoFactorVerifierInterface
+	challenges       *signedtoken.Signer
+	challengeTTL     time.Duration
+	accessTokens     AccessTokenIssuerInterface
+	revoker          SessionRevokerInterface
+	jwtConfig        config.JWTConfig
+
+	dummyHashOnce sync.Once
+	dummyHash     string
 }
 
 // NewAuthService create new AuthService instance
-func NewAuthService(userRepo UserRepositoryInterface, jwtSecret string) *AuthService {
+// A nil loginLockout disables account lockout, a nil metrics disables counting
+func NewAuthService(userRepo UserRepositoryInterface, refreshTokenRepo RefreshTokenRepositoryInterface, loginLockout LoginLockoutInterface, transactor TransactorInterface, metrics AuthMetricsInterface, verifier EmailVerifierInterface, passwordPolicy passwordpolicy.Policy, twoFactor TwoFactorVerifierInterface, twoFactorConfig config.TwoFactorConfig, accessTokens AccessTokenIssuerInterface, revoker SessionRevokerInterface, jwtConfig config.JWTConfig) *AuthService {
 	return &AuthService{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		loginLockout:     loginLockout,
+		transactor:       transactor,
+		metrics:          metrics,
+		verifier:         verifier,
+		passwordPolicy:   passwordPolicy,
+		twoFactor:        twoFactor,
+		challenges:       signedtoken.New(twoFactorConfig.Secret, "two-factor-challenge"),
+		challengeTTL:     twoFactorConfig.ChallengeTTL,
+		accessTokens:     accessTokens,
+		revoker:          revoker,
+		jwtConfig:        jwtConfig,
 	}
 }
 
 // Register handles user registration
-func (s *AuthService) Register(req *schemas.RegisterRequest) (*schemas.AuthResponse, error) {
+// The account cannot log in until the emailed verification link is opened
+func (s *AuthService) Register(ctx context.Context, req *schemas.RegisterRequest) (_ *schemas.UserResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Register")
+	defer span.End()
+	defer func() { s.recordAttempt("register", err) }()
+
 	// Check if the user already exists
-	_, err := s.userRepo.GetByEmail(req.Email)
+	_, err = s.userRepo.GetByEmail(ctx, req.Email)
 	if err == nil {
-		return nil, errors.New("email already in use")
+		return nil, ErrEmailTaken
+	}
+	if !apperror.IsKind(err, apperror.KindNotFound) {
+		return nil, err
+	}
+
+	if violations := s.passwordPolicy.Check("Password", req.Password, req.Email, req.Username); len(violations) > 0 {
+		return nil, ErrWeakPassword.WithDetails(violations)
 	}
 
 	// hash password
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
-		return nil, errors.New("could not hash password")
+		return nil, apperror.Internal(fmt.Errorf("hash password: %w", err))
 	}
 
 	// Create a user model
@@ -45,55 +135,397 @@ func (s *AuthService) Register(req *schemas.RegisterRequest) (*schemas.AuthRespo
 	}
 
 	// save to a database
-	if err := s.userRepo.Create(user); err != nil {
-		return nil, errors.New("could not create user")
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
 	}
 
-	// Generate jwt token
-	token, err := jwt.GenerateToken(user.ID, user.Email, user.Password, s.jwtSecret)
-	if err != nil {
-		return nil, errors.New("could not generate token")
+	// a failed email does not undo the registration, the user can ask for a resend
+	if err := s.verifier.SendVerification(ctx, user); err != nil {
+		pkgLogger.FromContext(ctx).WithError(err).Error("send verification email")
 	}
 
-	// return response
-	return &schemas.AuthResponse{
-		Token: token,
-		User:  schemas.UserToResponse(user),
-	}, nil
+	response := schemas.UserToResponse(user)
+	return &response, nil
 }
 
 // Login handles user login
-func (s *AuthService) Login(req *schemas.LoginRequest) (*schemas.AuthResponse, error) {
+// Unknown emails and wrong passwords return the same error so accounts cannot be enumerated.
+// With two-factor authentication enabled only a challenge is returned, see VerifyTwoFactor.
+func (s *AuthService) Login(ctx context.Context, req *schemas.LoginRequest) (_ *schemas.LoginResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Login")
+	defer span.End()
+	defer func() { s.recordAttempt("login", err) }()
+
+	// keyed like the per account login rate limit
+	lockoutKey := ratelimit.NormalizeKey(req.Email)
+
+	// reject locked accounts before checking the password
+	if err := s.checkLockout(ctx, lockoutKey); err != nil {
+		return nil, err
+	}
+
 	// Find user by email
-	user, err := s.userRepo.GetByEmail(req.Email)
-	if err != nil {
-		return nil, errors.New("could not find user")
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil && !apperror.IsKind(err, apperror.KindNotFound) {
+		return nil, err
 	}
 
-	// check password
+	// check password, comparing against a dummy hash for unknown emails keeps the timing the same
+	if err != nil {
+		utils.CheckPasswordHash(req.Password, s.getDummyHash())
+		return nil, s.loginFailed(ctx, lockoutKey, ErrInvalidCredentials)
+	}
 	if !utils.CheckPasswordHash(req.Password, user.Password) {
-		return nil, errors.New("invalid password")
+		return nil, s.loginFailed(ctx, lockoutKey, ErrInvalidCredentials)
+	}
+
+	// with 2FA the counter is only reset once the code is accepted, otherwise
+	// logging in with the password between guesses would reset code failures
+	if !user.IsTwoFactorEnabled() {
+		s.resetLockout(ctx, lockoutKey)
+	}
+
+	// the plaintext is only available here, so legacy bcrypt hashes and hashes made
+	// with old parameters are upgraded to the current hasher now
+	if utils.NeedsRehash(user.Password) {
+		s.rehashPassword(ctx, user, req.Password)
+	}
+
+	// only checked after the password so it does not reveal which emails exist
+	if !user.IsEmailVerified() {
+		return nil, ErrEmailNotVerified
+	}
+
+	if user.IsTwoFactorEnabled() {
+		challenge, err := s.newTwoFactorChallenge(user)
+		if err != nil {
+			return nil, err
+		}
+		return &schemas.LoginResponse{TwoFactor: challenge}, nil
 	}
 
-	// generate jwt token
-	token, err := jwt.GenerateToken(user.ID, user.Email, user.Password, s.jwtSecret)
+	// every login starts a new refresh token family
+	tokens, err := s.issueTokens(ctx, user, "")
 	if err != nil {
-		return nil, errors.New("could not generate token")
+		return nil, err
 	}
+	return &schemas.LoginResponse{AuthResponse: tokens}, nil
+}
 
-	return &schemas.AuthResponse{
-		Token: token,
-		User:  schemas.UserToResponse(user),
-	}, nil
+// VerifyTwoFactor completes a login that returned a challenge. Failed codes
+// count towards the same account lockout as failed passwords.
+func (s *AuthService) VerifyTwoFactor(ctx context.Context, req *schemas.TwoFactorVerifyRequest) (_ *schemas.AuthResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.VerifyTwoFactor")
+	defer span.End()
+	defer func() { s.recordAttempt("two_factor", err) }()
+
+	var claims twoFactorClaims
+	if err := s.challenges.Verify(req.ChallengeToken, &claims); err != nil {
+		if errors.Is(err, signedtoken.ErrExpired) {
+			return nil, ErrTwoFactorChallengeExpired
+		}
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if apperror.IsKind(err, apperror.KindNotFound) {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lockoutKey := ratelimit.NormalizeKey(user.Email)
+	if err := s.checkLockout(ctx, lockoutKey); err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactor.VerifyCode(ctx, user, req.Code); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			return nil, s.loginFailed(ctx, lockoutKey, ErrInvalidTwoFactorCode)
+		}
+		return nil, err
+	}
+	s.resetLockout(ctx, lockoutKey)
+
+	return s.issueTokens(ctx, user, "")
+}
+
+// Refresh rotates a refresh token: the presented token is revoked and a new
+// pair is issued in the same family. Presenting an already-used token is
+// treated as theft and revokes the whole family.
+func (s *AuthService) Refresh(ctx context.Context, req *schemas.RefreshTokenRequest) (_ *schemas.AuthResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Refresh")
+	defer span.End()
+	defer func() { s.recordAttempt("refresh", err) }()
+
+	stored, err := s.lookupRefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// reuse detection
+	if stored.IsRevoked() {
+		if err := s.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	if stored.IsExpired() {
+		return nil, ErrRefreshTokenExpired
+	}
+
+	if stored.User == nil {
+		return nil, ErrUserNotFound
+	}
+
+	// the new token is only kept when the old one is revoked
+	var result *schemas.AuthResponse
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		tokens, newToken, err := s.generateTokens(ctx, stored.User, stored.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		// lose the race to a concurrent refresh with the same token -> reuse
+		revoked, err := s.refreshTokenRepo.Revoke(ctx, stored.ID, &newToken.ID)
+		if err != nil {
+			return err
+		}
+		if !revoked {
+			return ErrRefreshTokenReused
+		}
+
+		result = tokens
+		return nil
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		// outside the rolled back transaction so the family stays revoked
+		_ = s.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Logout revokes the refresh token family of the presented token
+func (s *AuthService) Logout(ctx context.Context, req *schemas.LogoutRequest) error {
+	ctx, span := tracing.Start(ctx, "AuthService.Logout")
+	defer span.End()
+
+	stored, err := s.lookupRefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return s.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID)
+}
+
+// ChangePassword replaces the password of a logged in user after checking the current one.
+// Every outstanding refresh and access token of the user is revoked and a fresh pair is
+// returned, so other sessions end while the caller stays logged in.
+func (s *AuthService) ChangePassword(ctx context.Context, userID uint, req *schemas.ChangePasswordRequest) (_ *schemas.AuthResponse, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ChangePassword")
+	defer span.End()
+	defer func() { s.recordAttempt("change_password", err) }()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utils.CheckPasswordHash(req.CurrentPassword, user.Password) {
+		return nil, ErrInvalidCurrentPassword
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return nil, ErrPasswordUnchanged
+	}
+	if violations := s.passwordPolicy.Check("NewPassword", req.NewPassword, user.Email, user.Name); len(violations) > 0 {
+		return nil, ErrWeakPassword.WithDetails(violations)
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return nil, apperror.Internal(fmt.Errorf("hash password: %w", err))
+	}
+
+	// the password only changes together with ending the other sessions
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		user.Password = hashedPassword
+		if err := s.userRepo.Update(ctx, user.ID, user); err != nil {
+			return err
+		}
+		return s.refreshTokenRepo.RevokeAllForUser(ctx, user.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// after the commit, a rolled back change must not log everyone out, and
+	// before issuing, so only the new access token outlives the revocation
+	if err := s.revoker.RevokeUser(ctx, user.ID); err != nil {
+		pkgLogger.FromContext(ctx).WithError(err).WithField("revoked_user_id", user.ID).Error("revoke access tokens")
+		return nil, apperror.Internal(fmt.Errorf("revoke access tokens: %w", err))
+	}
+
+	return s.issueTokens(ctx, user, "")
 }
 
 // GetProfile handles get user profile
-func (s *AuthService) GetProfile(userID uint) (*schemas.UserResponse, error) {
-	user, err := s.userRepo.GetByID(userID)
+func (s *AuthService) GetProfile(ctx context.Context, userID uint) (*schemas.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.GetProfile")
+	defer span.End()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		return nil, errors.New("could not find user")
+		return nil, err
 	}
 
 	response := schemas.UserToResponse(user)
 	return &response, nil
 }
+
+// recordAttempt counts the outcome of an authentication action
+func (s *AuthService) recordAttempt(action string, err error) {
+	if s.metrics != nil {
+		s.metrics.AuthAttempt(action, err == nil)
+	}
+}
+
+// checkLockout returns ErrAccountLocked when the account is locked after too many failed logins.
+// A failing lockout store rejects the login instead of turning brute-force protection off.
+func (s *AuthService) checkLockout(ctx context.Context, lockoutKey string) error {
+	if s.loginLockout == nil {
+		return nil
+	}
+
+	locked, err := s.loginLockout.Locked(ctx, lockoutKey)
+	if err != nil {
+		return apperror.Internal(fmt.Errorf("check login lockout: %w", err))
+	}
+	if locked > 0 {
+		return ErrAccountLocked
+	}
+	return nil
+}
+
+// resetLockout clears the failed login count after a successful login
+func (s *AuthService) resetLockout(ctx context.Context, lockoutKey string) {
+	if s.loginLockout == nil {
+		return
+	}
+
+	if err := s.loginLockout.Reset(ctx, lockoutKey); err != nil {
+		pkgLogger.FromContext(ctx).WithError(err).Error("login lockout reset")
+	}
+}
+
+// loginFailed records a failed attempt and returns failure, or ErrAccountLocked once the account locks
+func (s *AuthService) loginFailed(ctx context.Context, lockoutKey string, failure error) error {
+	if s.loginLockout == nil {
+		return failure
+	}
+
+	locked, err := s.loginLockout.Fail(ctx, lockoutKey)
+	if err != nil {
+		pkgLogger.FromContext(ctx).WithError(err).Error("login lockout record")
+	}
+	if locked > 0 {
+		return ErrAccountLocked
+	}
+
+	return failure
+}
+
+// rehashPassword stores a new hash of password, a failure only means the upgrade is retried on the next login
+func (s *AuthService) rehashPassword(ctx context.Context, user *models.User, password string) {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		pkgLogger.FromContext(ctx).WithError(err).Error("rehash password")
+		return
+	}
+
+	user.Password = hashedPassword
+	if err := s.userRepo.Update(ctx, user.ID, &models.User{Password: hashedPassword}); err != nil {
+		pkgLogger.FromContext(ctx).WithError(err).Error("store rehashed password")
+	}
+}
+
+// getDummyHash returns a password hash used when the email does not exist
+func (s *AuthService) getDummyHash() string {
+	s.dummyHashOnce.Do(func() {
+		s.dummyHash, _ = utils.HashPassword("dummy-password-for-timing")
+	})
+	return s.dummyHash
+}
+
+// newTwoFactorChallenge signs a short-lived token standing for a correct password
+func (s *AuthService) newTwoFactorChallenge(user *models.User) (*schemas.TwoFactorChallenge, error) {
+	token, err := s.challenges.Sign(twoFactorClaims{UserID: user.ID}, s.challengeTTL)
+	if err != nil {
+		return nil, apperror.Internal(fmt.Errorf("sign two-factor challenge: %w", err))
+	}
+
+	return &schemas.TwoFactorChallenge{
+		ChallengeToken: token,
+		ExpiresAt:      time.Now().Add(s.challengeTTL),
+	}, nil
+}
+
+// lookupRefreshToken finds the stored record for a presented refresh token
+func (s *AuthService) lookupRefreshToken(ctx context.Context, token string) (*models.RefreshToken, error) {
+	stored, err := s.refreshTokenRepo.GetByHash(ctx, jwt.HashToken(token))
+	if apperror.IsKind(err, apperror.KindNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	return stored, err
+}
+
+// issueTokens generates an access token and a refresh token for the user
+func (s *AuthService) issueTokens(ctx context.Context, user *models.User, familyID string) (*schemas.AuthResponse, error) {
+	result, _, err := s.generateTokens(ctx, user, familyID)
+	return result, err
+}
+
+// generateTokens signs an access token and persists a new refresh token.
+// An empty familyID starts a new family.
+func (s *AuthService) generateTokens(ctx context.Context, user *models.User, familyID string) (*schemas.AuthResponse, *models.RefreshToken, error) {
+	accessToken, accessExpiresAt, err := s.accessTokens.GenerateToken(user.ID, user.Email, user.Role, s.jwtConfig.AccessTTL)
+	if err != nil {
+		return nil, nil, apperror.Internal(fmt.Errorf("generate access token: %w", err))
+	}
+
+	refreshToken, err := jwt.GenerateOpaqueToken()
+	if err != nil {
+		return nil, nil, apperror.Internal(fmt.Errorf("generate refresh token: %w", err))
+	}
+
+	if familyID == "" {
+		familyID, err = jwt.GenerateOpaqueToken()
+		if err != nil {
+			return nil, nil, apperror.Internal(fmt.Errorf("generate token family: %w", err))
+		}
+	}
+
+	stored := &models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: jwt.HashToken(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(s.jwtConfig.RefreshTTL),
+	}
+	if err := s.refreshTokenRepo.Create(ctx, stored); err != nil {
+		return nil, nil, err
+	}
+
+	return &schemas.AuthResponse{
+		Token:                 accessToken,
+		TokenType:             "Bearer",
+		ExpiresAt:             accessExpiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: stored.ExpiresAt,
+		User:                  schemas.UserToResponse(user),
+	}, stored, nil
+}
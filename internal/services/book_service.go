@@ -1,33 +1,52 @@
 package services
 
 import (
-	"errors"
+	"context"
+	"fmt"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/policy"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/schemas"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/cache"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/response"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/tracing"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/utils"
+	"time"
 )
 
 // BookRepositoryInterface defines what BookService needs from repository
 type BookRepositoryInterface interface {
-	Create(book *models.Book) error
-	GetAll(params *utils.PaginationParams) ([]*models.Book, int64, error)
-	GetById(id uint) (*models.Book, error)
-	Update(id uint, book *models.Book) error
-	Delete(id uint) error
+	Create(ctx context.Context, book *models.Book) error
+	GetAll(ctx context.Context, params *utils.PaginationParams) ([]*models.Book, int64, error)
+	GetAllKeyset(ctx context.Context, params *utils.PaginationParams) ([]*models.Book, *utils.CursorPage, error)
+	GetById(ctx context.Context, id uint) (*models.Book, error)
+	Update(ctx context.Context, id uint, book *models.Book, ownerID *uint) error
+	Delete(ctx context.Context, id uint, ownerID *uint) error
 }
 
 // BookService handles book management logic
 type BookService struct {
 	bookRepo BookRepositoryInterface
+	cache    resultCache
+}
+
+// bookListResult is the cached result of GetAll
+type bookListResult struct {
+	Books      []schemas.BookResponse `json:"books"`
+	Pagination *response.Pagination   `json:"pagination"`
 }
 
 // NewBookService create a new BookService instance
-func NewBookService(bookRepo BookRepositoryInterface) *BookService {
-	return &BookService{bookRepo: bookRepo}
+// A nil cacheStore disables caching
+func NewBookService(bookRepo BookRepositoryInterface, cacheStore cache.Store, cacheTTL time.Duration) *BookService {
+	return &BookService{
+		bookRepo: bookRepo,
+		cache:    resultCache{store: cacheStore, ttl: cacheTTL, prefix: "books:"},
+	}
 }
 
-func (s *BookService) Create(req *schemas.CreateBookRequest, userId uint) (*schemas.BookResponse, error) {
+func (s *BookService) Create(ctx context.Context, req *schemas.CreateBookRequest, userId uint) (*schemas.BookResponse, error) {
+	ctx, span := tracing.Start(ctx, "BookService.Create")
+	defer span.End()
 
 	// Create a book model
 	book := &models.Book{
@@ -38,29 +57,43 @@ func (s *BookService) Create(req *schemas.CreateBookRequest, userId uint) (*sche
 	}
 
 	// save to database
-	err := s.bookRepo.Create(book)
+	err := s.bookRepo.Create(ctx, book)
 	if err != nil {
-		return nil, errors.New("could not create book")
+		return nil, err
 	}
 
 	// Reload book with user data
-	bookWithUser, err := s.bookRepo.GetById(book.UserID)
+	bookWithUser, err := s.bookRepo.GetById(ctx, book.ID)
 	if err != nil {
-		return nil, errors.New("could not get book")
+		return nil, err
 	}
 
+	// new book changes list results
+	s.cache.invalidate(ctx, nil, "list:")
+
 	response := schemas.BookToResponse(bookWithUser)
 	return &response, nil
 }
 
-func (s *BookService) GetAll(params *utils.PaginationParams) ([]schemas.BookResponse, *response.Pagination, error) {
+func (s *BookService) GetAll(ctx context.Context, params *utils.PaginationParams) ([]schemas.BookResponse, *response.Pagination, cache.Status, error) {
+	ctx, span := tracing.Start(ctx, "BookService.GetAll")
+	defer span.End()
+
 	// set default value
 	params.GetDefaults()
 
+	// serve from cache when possible
+	cacheKey := "list:" + params.CacheKey()
+	var cached bookListResult
+	status := s.cache.get(ctx, cacheKey, &cached)
+	if status == cache.StatusHit {
+		return cached.Books, cached.Pagination, status, nil
+	}
+
 	// get book from repository
-	books, total, err := s.bookRepo.GetAll(params)
+	books, pagination, err := s.findBooks(ctx, params)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, status, err
 	}
 
 	// convert format response
@@ -69,29 +102,48 @@ func (s *BookService) GetAll(params *utils.PaginationParams) ([]schemas.BookResp
 		bookResponses = append(bookResponses, schemas.BookToResponse(book))
 	}
 
-	// calculate pagination
-	pagination := utils.CalculatePagination(params.Page, params.Size, total)
-	return bookResponses, pagination, nil
+	s.cache.set(ctx, cacheKey, bookListResult{Books: bookResponses, Pagination: pagination})
+	return bookResponses, pagination, status, nil
 }
 
-func (s *BookService) GetById(id uint) (*schemas.BookResponse, error) {
+func (s *BookService) GetById(ctx context.Context, id uint) (*schemas.BookResponse, cache.Status, error) {
+	ctx, span := tracing.Start(ctx, "BookService.GetById")
+	defer span.End()
+
+	// serve from cache when possible
+	cacheKey := bookCacheKey(id)
+	var cached schemas.BookResponse
+	status := s.cache.get(ctx, cacheKey, &cached)
+	if status == cache.StatusHit {
+		return &cached, status, nil
+	}
+
 	// get book by id from repository
-	book, err := s.bookRepo.GetById(id)
+	book, err := s.bookRepo.GetById(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, status, err
 	}
 
 	response := schemas.BookToResponse(book)
-	return &response, nil
+	s.cache.set(ctx, cacheKey, response)
+	return &response, status, nil
 }
 
-func (s *BookService) Update(id uint, req *schemas.UpdateBookRequest) (*schemas.BookResponse, error) {
+func (s *BookService) Update(ctx context.Context, actor policy.Actor, id uint, req *schemas.UpdateBookRequest) (*schemas.BookResponse, error) {
+	ctx, span := tracing.Start(ctx, "BookService.Update")
+	defer span.End()
+
 	// Get book by id
-	book, err := s.bookRepo.GetById(id)
+	book, err := s.bookRepo.GetById(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	// only the owner or an admin can update
+	if err := policy.CanMutateBook(actor, book); err != nil {
+		return nil, err
+	}
+
 	// update fields if provide
 	if req.Title != "" {
 		book.Title = req.Title
@@ -104,26 +156,65 @@ func (s *BookService) Update(id uint, req *schemas.UpdateBookRequest) (*schemas.
 	}
 
 	// save update to repository
-	err = s.bookRepo.Update(id, book)
+	err = s.bookRepo.Update(ctx, id, book, policy.BookOwnerScope(actor))
 	if err != nil {
 		return nil, err
 	}
 
+	s.cache.invalidate(ctx, []string{bookCacheKey(id)}, "list:")
+
 	response := schemas.BookToResponse(book)
 	return &response, nil
 }
 
-func (s *BookService) Delete(id uint) error {
+func (s *BookService) Delete(ctx context.Context, actor policy.Actor, id uint) error {
+	ctx, span := tracing.Start(ctx, "BookService.Delete")
+	defer span.End()
+
 	// get book by id
-	_, err := s.bookRepo.GetById(id)
+	book, err := s.bookRepo.GetById(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	err = s.bookRepo.Delete(id)
+	// only the owner or an admin can delete
+	if err := policy.CanMutateBook(actor, book); err != nil {
+		return err
+	}
+
+	err = s.bookRepo.Delete(ctx, id, policy.BookOwnerScope(actor))
 	if err != nil {
 		return err
 	}
 
+	s.cache.invalidate(ctx, []string{bookCacheKey(id)}, "list:")
+
 	return nil
 }
+
+// InvalidateCache drops every cached book result. Cached books embed their
+// owner, so changes to users go through here too.
+func (s *BookService) InvalidateCache(ctx context.Context) {
+	s.cache.invalidate(ctx, nil, "")
+}
+
+// findBooks runs the list query in the pagination mode the client asked for
+func (s *BookService) findBooks(ctx context.Context, params *utils.PaginationParams) ([]*models.Book, *response.Pagination, error) {
+	if params.Keyset {
+		books, page, err := s.bookRepo.GetAllKeyset(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		return books, utils.CalculateCursorPagination(params.Size, page), nil
+	}
+
+	books, total, err := s.bookRepo.GetAll(ctx, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return books, utils.CalculatePagination(params.Page, params.Size, total), nil
+}
+
+func bookCacheKey(id uint) string {
+	return fmt.Sprintf("id:%d", id)
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/config"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/schemas"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/apperror"
+	pkgLogger "github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/logger"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/mailer"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/signedtoken"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/tracing"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MailerInterface delivers emails
+type MailerInterface interface {
+	Send(ctx context.Context, msg mailer.Message) error
+}
+
+// verificationClaims are signed into verification tokens. Binding the email
+// means a token stops working once the address changes.
+type verificationClaims struct {
+	UserID uint   `json:"uid"`
+	Email  string `json:"email"`
+}
+
+// EmailVerificationService issues and checks email verification tokens
+type EmailVerificationService struct {
+	userRepo UserRepositoryInterface
+	mailer   MailerInterface
+	signer   *signedtoken.Signer
+	cfg      config.EmailVerificationConfig
+}
+
+// NewEmailVerificationService create new EmailVerificationService instance
+func NewEmailVerificationService(userRepo UserRepositoryInterface, mailer MailerInterface, cfg config.EmailVerificationConfig) *EmailVerificationService {
+	return &EmailVerificationService{
+		userRepo: userRepo,
+		mailer:   mailer,
+		signer:   signedtoken.New(cfg.Secret, "email-verification"),
+		cfg:      cfg,
+	}
+}
+
+// SendVerification emails the user a link with a signed verification token
+func (s *EmailVerificationService) SendVerification(ctx context.Context, user *models.User) error {
+	ctx, span := tracing.Start(ctx, "EmailVerificationService.SendVerification")
+	defer span.End()
+
+	token, err := s.signer.Sign(verificationClaims{UserID: user.ID, Email: user.Email}, s.cfg.TokenTTL)
+	if err != nil {
+		return apperror.Internal(fmt.Errorf("sign verification token: %w", err))
+	}
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %s.\n",
+			user.Name, s.verificationLink(token), s.cfg.TokenTTL),
+	})
+	if err != nil {
+		return apperror.Internal(fmt.Errorf("send verification email: %w", err))
+	}
+
+	return nil
+}
+
+// Verify marks the email in the token as verified, each token works once
+func (s *EmailVerificationService) Verify(ctx context.Context, req *schemas.VerifyEmailRequest) (*schemas.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "EmailVerificationService.Verify")
+	defer span.End()
+
+	var claims verificationClaims
+	if err := s.signer.Verify(req.Token, &claims); err != nil {
+		if errors.Is(err, signedtoken.ErrExpired) {
+			return nil, ErrVerificationTokenExpired
+		}
+		return nil, ErrInvalidVerificationToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if apperror.IsKind(err, apperror.KindNotFound) {
+		return nil, ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(user.Email, claims.Email) {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	verifiedAt := time.Now()
+	verified, err := s.userRepo.MarkEmailVerified(ctx, user.ID, verifiedAt)
+	if err != nil {
+		return nil, err
+	}
+	if !verified {
+		return nil, ErrEmailAlreadyVerified
+	}
+
+	user.EmailVerifiedAt = &verifiedAt
+	response := schemas.UserToResponse(user)
+	return &response, nil
+}
+
+// Resend emails a new verification link. It succeeds silently for unknown
+// or already verified addresses so accounts cannot be enumerated.
+func (s *EmailVerificationService) Resend(ctx context.Context, req *schemas.ResendVerificationRequest) error {
+	ctx, span := tracing.Start(ctx, "EmailVerificationService.Resend")
+	defer span.End()
+
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if apperror.IsKind(err, apperror.KindNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.IsEmailVerified() {
+		pkgLogger.FromContext(ctx).Info("verification resend skipped, email already verified")
+		return nil
+	}
+
+	return s.SendVerification(ctx, user)
+}
+
+func (s *EmailVerificationService) verificationLink(token string) string {
+	separator := "?"
+	if strings.Contains(s.cfg.URL, "?") {
+		separator = "&"
+	}
+	return s.cfg.URL + separator + "token=" + url.QueryEscape(token)
+}
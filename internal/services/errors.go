@@ -0,0 +1,32 @@
+package services
+
+import "github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/apperror"
+
+// Domain errors returned by services, mapped to HTTP responses by the global error handler
+var (
+	ErrEmailTaken          = apperror.Conflict("EMAIL_TAKEN", "email already in use")
+	ErrUserNotFound        = apperror.Unauthorized("USER_NOT_FOUND", "could not find user")
+	ErrInvalidCredentials  = apperror.Unauthorized("INVALID_CREDENTIALS", "invalid credentials")
+	ErrAccountLocked       = apperror.RateLimited("ACCOUNT_LOCKED", "too many failed login attempts, try again later")
+	ErrInvalidRefreshToken = apperror.Unauthorized("INVALID_REFRESH_TOKEN", "invalid refresh token")
+	ErrRefreshTokenExpired = apperror.Unauthorized("REFRESH_TOKEN_EXPIRED", "refresh token expired")
+	ErrRefreshTokenReused  = apperror.Unauthorized("REFRESH_TOKEN_REUSED", "refresh token reuse detected")
+	ErrEmailNotVerified    = apperror.Forbidden("EMAIL_NOT_VERIFIED", "email address has not been verified")
+
+	ErrInvalidVerificationToken = apperror.Validation("INVALID_VERIFICATION_TOKEN", "invalid verification token")
+	ErrVerificationTokenExpired = apperror.Validation("VERIFICATION_TOKEN_EXPIRED", "verification token expired")
+	ErrEmailAlreadyVerified     = apperror.Conflict("EMAIL_ALREADY_VERIFIED", "email address is already verified")
+
+	ErrInvalidResetToken = apperror.Validation("INVALID_RESET_TOKEN", "invalid password reset token")
+	ErrResetTokenExpired = apperror.Validation("RESET_TOKEN_EXPIRED", "password reset token expired")
+
+	ErrInvalidCurrentPassword = apperror.Validation("INVALID_CURRENT_PASSWORD", "current password is incorrect")
+	ErrPasswordUnchanged      = apperror.Validation("PASSWORD_UNCHANGED", "new password must differ from the current password")
+	ErrWeakPassword           = apperror.Validation("WEAK_PASSWORD", "password does not meet the password policy")
+
+	ErrTwoFactorAlreadyEnabled   = apperror.Conflict("TWO_FACTOR_ALREADY_ENABLED", "two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled      = apperror.Validation("TWO_FACTOR_NOT_ENROLLED", "start two-factor enrollment first")
+	ErrInvalidTwoFactorCode      = apperror.Validation("INVALID_TWO_FACTOR_CODE", "invalid two-factor code")
+	ErrInvalidTwoFactorChallenge = apperror.Unauthorized("INVALID_TWO_FACTOR_CHALLENGE", "invalid two-factor challenge")
+	ErrTwoFactorChallengeExpired = apperror.Unauthorized("TWO_FACTOR_CHALLENGE_EXPIRED", "two-factor challenge expired, log in again")
+)
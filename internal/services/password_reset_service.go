@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/config"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/schemas"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/apperror"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/jwt"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/mailer"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/passwordpolicy"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/tracing"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/utils"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PasswordResetTokenRepositoryInterface defines what PasswordResetService needs to persist reset tokens
+type PasswordResetTokenRepositoryInterface interface {
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	GetByHash(ctx context.Context, hash string) (*models.PasswordResetToken, error)
+	MarkUsed(ctx context.Context, id uint) (bool, error)
+	InvalidateForUser(ctx context.Context, userID uint) error
+}
+
+// PasswordResetService handles forgotten passwords through emailed one-time tokens
+type PasswordResetService struct {
+	userRepo         UserRepositoryInterface
+	resetTokenRepo   PasswordResetTokenRepositoryInterface
+	refreshTokenRepo RefreshTokenRepositoryInterface
+	transactor       TransactorInterface
+	mailer           MailerInterface
+	passwordPolicy   passwordpolicy.Policy
+	revoker          SessionRevokerInterface
+	cfg              config.PasswordResetConfig
+}
+
+// NewPasswordResetService create new PasswordResetService instance
+func NewPasswordResetService(userRepo UserRepositoryInterface, resetTokenRepo PasswordResetTokenRepositoryInterface, refreshTokenRepo RefreshTokenRepositoryInterface, transactor TransactorInterface, mailer MailerInterface, passwordPolicy passwordpolicy.Policy, revoker SessionRevokerInterface, cfg config.PasswordResetConfig) *PasswordResetService {
+	return &PasswordResetService{
+		userRepo:         userRepo,
+		resetTokenRepo:   resetTokenRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		transactor:       transactor,
+		mailer:           mailer,
+		passwordPolicy:   passwordPolicy,
+		revoker:          revoker,
+		cfg:              cfg,
+	}
+}
+
+// ForgotPassword emails a reset link, replacing any earlier link. It succeeds
+// silently for unknown addresses so accounts cannot be enumerated.
+func (s *PasswordResetService) ForgotPassword(ctx context.Context, req *schemas.ForgotPasswordRequest) error {
+	ctx, span := tracing.Start(ctx, "PasswordResetService.ForgotPassword")
+	defer span.End()
+
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if apperror.IsKind(err, apperror.KindNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := jwt.GenerateOpaqueToken()
+	if err != nil {
+		return apperror.Internal(fmt.Errorf("generate reset token: %w", err))
+	}
+
+	// only the newest link works
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.resetTokenRepo.InvalidateForUser(ctx, user.ID); err != nil {
+			return err
+		}
+		return s.resetTokenRepo.Create(ctx, &models.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: jwt.HashToken(token),
+			ExpiresAt: time.Now().Add(s.cfg.TokenTTL),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account. Open the link below to choose a new one:\n\n%s\n\nThe link expires in %s. If you did not ask for this, you can ignore this email.\n",
+			user.Name, s.resetLink(token), s.cfg.TokenTTL),
+	})
+	if err != nil {
+		return apperror.Internal(fmt.Errorf("send reset email: %w", err))
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password with a reset token and signs the user out
+// everywhere by revoking all refresh and access tokens.
+func (s *PasswordResetService) ResetPassword(ctx context.Context, req *schemas.ResetPasswordRequest) error {
+	ctx, span := tracing.Start(ctx, "PasswordResetService.ResetPassword")
+	defer span.End()
+
+	stored, err := s.resetTokenRepo.GetByHash(ctx, jwt.HashToken(req.Token))
+	if apperror.IsKind(err, apperror.KindNotFound) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+	if stored.IsUsed() {
+		return ErrInvalidResetToken
+	}
+	if stored.IsExpired() {
+		return ErrResetTokenExpired
+	}
+
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
+	if apperror.IsKind(err, apperror.KindNotFound) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+
+	// a rejected password leaves the token usable for another attempt
+	if violations := s.passwordPolicy.Check("Password", req.Password, user.Email, user.Name); len(violations) > 0 {
+		return ErrWeakPassword.WithDetails(violations)
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return apperror.Internal(fmt.Errorf("hash password: %w", err))
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// lose the race to a concurrent reset with the same token
+		used, err := s.resetTokenRepo.MarkUsed(ctx, stored.ID)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidResetToken
+		}
+
+		user.Password = hashedPassword
+		if err := s.userRepo.Update(ctx, user.ID, user); err != nil {
+			return err
+		}
+
+		return s.refreshTokenRepo.RevokeAllForUser(ctx, user.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.revoker.RevokeUser(ctx, user.ID); err != nil {
+		return apperror.Internal(fmt.Errorf("revoke access tokens: %w", err))
+	}
+	return nil
+}
+
+func (s *PasswordResetService) resetLink(token string) string {
+	separator := "?"
+	if strings.Contains(s.cfg.URL, "?") {
+		separator = "&"
+	}
+	return s.cfg.URL + separator + "token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/cache"
+	pkgLogger "github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/logger"
+	"time"
+)
+
+// resultCache stores service results as JSON under a key prefix.
+// A nil store disables caching, cache failures are logged and never fail the request.
+type resultCache struct {
+	store  cache.Store
+	ttl    time.Duration
+	prefix string
+}
+
+// get loads key into dest and reports whether it was a hit
+func (c resultCache) get(ctx context.Context, key string, dest interface{}) cache.Status {
+	if c.store == nil {
+		return cache.StatusBypass
+	}
+
+	raw, err := c.store.Get(ctx, c.prefix+key)
+	if err != nil {
+		if !errors.Is(err, cache.ErrMiss) {
+			pkgLogger.FromContext(ctx).WithError(err).Error("cache get " + c.prefix + key)
+		}
+		return cache.StatusMiss
+	}
+
+	if err := json.Unmarshal(raw, dest); err != nil {
+		pkgLogger.FromContext(ctx).WithError(err).Error("cache decode " + c.prefix + key)
+		return cache.StatusMiss
+	}
+
+	return cache.StatusHit
+}
+
+func (c resultCache) set(ctx context.Context, key string, value interface{}) {
+	if c.store == nil {
+		return
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		pkgLogger.FromContext(ctx).WithError(err).Error("cache encode " + c.prefix + key)
+		return
+	}
+
+	if err := c.store.Set(ctx, c.prefix+key, raw, c.ttl); err != nil {
+		pkgLogger.FromContext(ctx).WithError(err).Error("cache set " + c.prefix + key)
+	}
+}
+
+// invalidate removes the given keys and everything under the given sub-prefixes
+func (c resultCache) invalidate(ctx context.Context, keys []string, prefixes ...string) {
+	if c.store == nil {
+		return
+	}
+
+	fullKeys := make([]string, len(keys))
+	for i, key := range keys {
+		fullKeys[i] = c.prefix + key
+	}
+	if err := c.store.Delete(ctx, fullKeys...); err != nil {
+		pkgLogger.FromContext(ctx).WithError(err).Error("cache delete " + c.prefix)
+	}
+
+	for _, prefix := range prefixes {
+		if err := c.store.DeletePrefix(ctx, c.prefix+prefix); err != nil {
+			pkgLogger.FromContext(ctx).WithError(err).Error("cache delete prefix " + c.prefix + prefix)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/cache"
+	"testing"
+	"time"
+)
+
+func TestResultCacheGetSet(t *testing.T) {
+	ctx := context.Background()
+
+	var dest string
+	if status := (resultCache{}).get(ctx, "1", &dest); status != cache.StatusBypass {
+		t.Errorf("get() without a store = %s, want BYPASS", status)
+	}
+
+	c := resultCache{store: cache.NewMemoryStore(), ttl: time.Minute, prefix: "books:"}
+	if status := c.get(ctx, "1", &dest); status != cache.StatusMiss {
+		t.Errorf("get() before set = %s, want MISS", status)
+	}
+
+	c.set(ctx, "1", "Dune")
+	if status := c.get(ctx, "1", &dest); status != cache.StatusHit || dest != "Dune" {
+		t.Errorf("get() after set = %s, %q, want HIT, Dune", status, dest)
+	}
+
+	// a value that no longer decodes into dest is a miss, not an error
+	var count int
+	if status := c.get(ctx, "1", &count); status != cache.StatusMiss {
+		t.Errorf("get() into another type = %s, want MISS", status)
+	}
+}
+
+func TestResultCacheInvalidate(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		keys       []string
+		prefixes   []string
+		wantCached []string
+	}{
+		{"keys", []string{"1"}, nil, []string{"2", "list:a", "list:b"}},
+		{"keys and prefix", []string{"1"}, []string{"list:"}, []string{"2"}},
+		{"everything", nil, []string{""}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := cache.NewMemoryStore()
+			books := resultCache{store: store, ttl: time.Minute, prefix: "books:"}
+			users := resultCache{store: store, ttl: time.Minute, prefix: "users:"}
+			for _, key := range []string{"1", "2", "list:a", "list:b"} {
+				books.set(ctx, key, key)
+			}
+			users.set(ctx, "1", "1")
+
+			books.invalidate(ctx, tt.keys, tt.prefixes...)
+
+			want := make(map[string]bool)
+			for _, key := range tt.wantCached {
+				want[key] = true
+			}
+			for _, key := range []string{"1", "2", "list:a", "list:b"} {
+				var dest string
+				if got := books.get(ctx, key, &dest) == cache.StatusHit; got != want[key] {
+					t.Errorf("books:%s cached = %v, want %v", key, got, want[key])
+				}
+			}
+
+			// other prefixes in the same store are untouched
+			var dest string
+			if status := users.get(ctx, "1", &dest); status != cache.StatusHit {
+				t.Errorf("users:1 = %s, want HIT", status)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package services
+
+import "context"
+
+// TransactorInterface runs several repository calls in one database transaction.
+// Repositories join the transaction through the ctx passed to fn.
+type TransactorInterface interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
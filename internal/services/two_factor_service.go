@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/config"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/schemas"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/apperror"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/jwt"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/secretbox"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/totp"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/tracing"
+	"strings"
+	"time"
+)
+
+// totpSkew accepts codes one time step before or after the current one to allow for clock drift
+const totpSkew = 1
+
+// recoveryCodeEncoding spells recovery codes in lowercase base32 so they are easy to type
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// TwoFactorRecoveryCodeRepositoryInterface defines what TwoFactorService needs to persist recovery codes
+type TwoFactorRecoveryCodeRepositoryInterface interface {
+	ReplaceForUser(ctx context.Context, userID uint, hashes []string) error
+	Use(ctx context.Context, userID uint, hash string) (bool, error)
+}
+
+// TwoFactorService handles TOTP enrollment and checks second factor codes
+type TwoFactorService struct {
+	userRepo         UserRepositoryInterface
+	recoveryCodeRepo TwoFactorRecoveryCodeRepositoryInterface
+	transactor       TransactorInterface
+	secrets          *secretbox.Box
+	cfg              config.TwoFactorConfig
+}
+
+// NewTwoFactorService create new TwoFactorService instance
+func NewTwoFactorService(userRepo UserRepositoryInterface, recoveryCodeRepo TwoFactorRecoveryCodeRepositoryInterface, transactor TransactorInterface, cfg config.TwoFactorConfig) *TwoFactorService {
+	return &TwoFactorService{
+		userRepo:         userRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		transactor:       transactor,
+		secrets:          secretbox.New(cfg.Secret),
+		cfg:              cfg,
+	}
+}
+
+// Enroll creates a new TOTP secret for the user and returns it with the otpauth URI
+// to add to an authenticator app. It only takes effect after Confirm, enrolling
+// again before that replaces the pending secret.
+func (s *TwoFactorService) Enroll(ctx context.Context, userID uint) (*schemas.TwoFactorEnrollResponse, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.Enroll")
+	defer span.End()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsTwoFactorEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, apperror.Internal(fmt.Errorf("generate totp secret: %w", err))
+	}
+	sealed, err := s.secrets.Seal(secret)
+	if err != nil {
+		return nil, apperror.Internal(fmt.Errorf("encrypt totp secret: %w", err))
+	}
+
+	// lose the race to a concurrent confirm
+	stored, err := s.userRepo.SetTOTPSecret(ctx, user.ID, sealed)
+	if err != nil {
+		return nil, err
+	}
+	if !stored {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	return &schemas.TwoFactorEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(s.cfg.Issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm enables two-factor authentication once the user proves their app
+// produces valid codes. The recovery codes are returned only this once.
+func (s *TwoFactorService) Confirm(ctx context.Context, userID uint, req *schemas.TwoFactorConfirmRequest) (*schemas.TwoFactorRecoveryCodesResponse, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.Confirm")
+	defer span.End()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsTwoFactorEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	step, err := s.validateTOTP(user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := s.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		advanced, err := s.userRepo.AdvanceTOTPStep(ctx, user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return ErrInvalidTwoFactorCode
+		}
+
+		enabled, err := s.userRepo.EnableTOTP(ctx, user.ID, time.Now())
+		if err != nil {
+			return err
+		}
+		if !enabled {
+			return ErrTwoFactorAlreadyEnabled
+		}
+
+		return s.recoveryCodeRepo.ReplaceForUser(ctx, user.ID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &schemas.TwoFactorRecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// VerifyCode checks the second login factor, a TOTP code or an unused recovery code.
+// Either works only once.
+func (s *TwoFactorService) VerifyCode(ctx context.Context, user *models.User, code string) error {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.VerifyCode")
+	defer span.End()
+
+	if !user.IsTwoFactorEnabled() {
+		return ErrTwoFactorNotEnrolled
+	}
+
+	code = strings.TrimSpace(code)
+	if !isTOTPCode(code) {
+		used, err := s.recoveryCodeRepo.Use(ctx, user.ID, hashRecoveryCode(code))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	step, err := s.validateTOTP(user, code)
+	if err != nil {
+		return err
+	}
+
+	advanced, err := s.userRepo.AdvanceTOTPStep(ctx, user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// validateTOTP checks code against the user's stored secret and returns its time step
+func (s *TwoFactorService) validateTOTP(user *models.User, code string) (int64, error) {
+	secret, err := s.secrets.Open(user.TOTPSecret)
+	if err != nil {
+		return 0, apperror.Internal(fmt.Errorf("decrypt totp secret: %w", err))
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return 0, ErrInvalidTwoFactorCode
+	}
+	return step, nil
+}
+
+// newRecoveryCodes returns the codes to show the user and the hashes to store
+func (s *TwoFactorService) newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, s.cfg.RecoveryCodes)
+	hashes := make([]string, s.cfg.RecoveryCodes)
+
+	for i := range codes {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, apperror.Internal(fmt.Errorf("generate recovery code: %w", err))
+		}
+
+		// 16 characters shown as xxxx-xxxx-xxxx-xxxx
+		raw := recoveryCodeEncoding.EncodeToString(b)
+		codes[i] = raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case, dashes and spaces so the code can be typed loosely
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return jwt.HashToken(normalized)
+}
+
+// isTOTPCode reports whether code looks like a TOTP code rather than a recovery code
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/config"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/totp"
+	"testing"
+	"time"
+)
+
+// totpStepRepo keeps totp_last_step in memory with the same rule as UserRepository.AdvanceTOTPStep
+type totpStepRepo struct {
+	UserRepositoryInterface
+	lastStep *int64
+}
+
+func (r *totpStepRepo) AdvanceTOTPStep(ctx context.Context, id uint, step int64) (bool, error) {
+	if r.lastStep != nil && *r.lastStep >= step {
+		return false, nil
+	}
+	r.lastStep = &step
+	return true, nil
+}
+
+func newTwoFactorUser(t *testing.T, s *TwoFactorService) (*models.User, string) {
+	t.Helper()
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := s.secrets.Seal(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enabledAt := time.Now()
+	return &models.User{ID: 1, TOTPSecret: sealed, TOTPEnabledAt: &enabledAt}, secret
+}
+
+func TestTwoFactorServiceVerifyCodeRejectsReplay(t *testing.T) {
+	codeAt := func(secret string, step int64) string {
+		code, err := totp.Code(secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+	current := totp.Step(time.Now())
+
+	tests := []struct {
+		name  string
+		codes func(secret string) []string
+		want  []error
+	}{
+		{
+			name:  "same code twice",
+			codes: func(secret string) []string { return []string{codeAt(secret, current), codeAt(secret, current)} },
+			want:  []error{nil, ErrInvalidTwoFactorCode},
+		},
+		{
+			name:  "older code after a newer one",
+			codes: func(secret string) []string { return []string{codeAt(secret, current), codeAt(secret, current-1)} },
+			want:  []error{nil, ErrInvalidTwoFactorCode},
+		},
+		{
+			name:  "newer code after an older one",
+			codes: func(secret string) []string { return []string{codeAt(secret, current-1), codeAt(secret, current)} },
+			want:  []error{nil, nil},
+		},
+		{
+			name:  "code outside the skew",
+			codes: func(secret string) []string { return []string{codeAt(secret, current-2)} },
+			want:  []error{ErrInvalidTwoFactorCode},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewTwoFactorService(&totpStepRepo{}, nil, nil, config.TwoFactorConfig{Secret: "a secret of at least thirty-two bytes"})
+			user, secret := newTwoFactorUser(t, s)
+
+			for i, code := range tt.codes(secret) {
+				if err := s.VerifyCode(context.Background(), user, code); !errors.Is(err, tt.want[i]) {
+					t.Errorf("VerifyCode() attempt %d error = %v, want %v", i+1, err, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -1,46 +1,106 @@
 package services
 
 import (
-	"errors"
+	"context"
+	"fmt"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/models"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/policy"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/internal/schemas"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/apperror"
+	pkgLogger "github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/logger"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/passwordpolicy"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/response"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/tracing"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/utils"
+	"time"
 )
 
 // UserRepositoryInterface defines what AuthService and UserService needs from repository
 type UserRepositoryInterface interface {
 	// Auth needs
 
-	Create(user *models.User) error
-	GetByEmail(email string) (*models.User, error)
-	GetByID(id uint) (*models.User, error)
+	Create(ctx context.Context, user *models.User) error
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByID(ctx context.Context, id uint) (*models.User, error)
 
 	// Management needs
 
-	Update(id uint, user *models.User) error
-	Delete(id uint) error
-	GetAll(params *utils.PaginationParams) ([]*models.User, int64, error)
+	Update(ctx context.Context, id uint, user *models.User) error
+	Delete(ctx context.Context, id uint) error
+	GetAll(ctx context.Context, params *utils.PaginationParams) ([]*models.User, int64, error)
+	GetAllKeyset(ctx context.Context, params *utils.PaginationParams) ([]*models.User, *utils.CursorPage, error)
+
+	// Verification needs
+
+	MarkEmailVerified(ctx context.Context, id uint, at time.Time) (bool, error)
+	ClearEmailVerified(ctx context.Context, id uint) error
+
+	// Two-factor needs
+
+	SetTOTPSecret(ctx context.Context, id uint, secret string) (bool, error)
+	EnableTOTP(ctx context.Context, id uint, at time.Time) (bool, error)
+	AdvanceTOTPStep(ctx context.Context, id uint, step int64) (bool, error)
+}
+
+// UserBookRepositoryInterface defines what UserService needs from the book repository
+type UserBookRepositoryInterface interface {
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
+
+// UserBookCacheInterface defines what UserService needs from the book result cache
+type UserBookCacheInterface interface {
+	InvalidateCache(ctx context.Context)
+}
+
+// UserRefreshTokenRepositoryInterface defines what UserService needs from the refresh token repository
+type UserRefreshTokenRepositoryInterface interface {
+	RevokeAllForUser(ctx context.Context, userID uint) error
+}
+
+// SessionRevokerInterface rejects a user's access tokens before they expire
+type SessionRevokerInterface interface {
+	RevokeUser(ctx context.Context, userID uint) error
 }
 
 // UserService handles user management logic
 type UserService struct {
-	userRepo UserRepositoryInterface
+	userRepo         UserRepositoryInterface
+	bookRepo         UserBookRepositoryInterface
+	bookCache        UserBookCacheInterface
+	refreshTokenRepo UserRefreshTokenRepositoryInterface
+	transactor       TransactorInterface
+	verifier         EmailVerifierInterface
+	passwordPolicy   passwordpolicy.Policy
+	revoker          SessionRevokerInterface
 }
 
 // NewUserService crate a new UserService instance
-func NewUserService(userRepo UserRepositoryInterface) *UserService {
+func NewUserService(userRepo UserRepositoryInterface, bookRepo UserBookRepositoryInterface, bookCache UserBookCacheInterface, refreshTokenRepo UserRefreshTokenRepositoryInterface, transactor TransactorInterface, verifier EmailVerifierInterface, passwordPolicy passwordpolicy.Policy, revoker SessionRevokerInterface) *UserService {
 	return &UserService{
-		userRepo: userRepo,
+		userRepo:         userRepo,
+		bookRepo:         bookRepo,
+		bookCache:        bookCache,
+		refreshTokenRepo: refreshTokenRepo,
+		transactor:       transactor,
+		verifier:         verifier,
+		passwordPolicy:   passwordPolicy,
+		revoker:          revoker,
 	}
 }
 
-func (s *UserService) GetAll(params *utils.PaginationParams) ([]schemas.UserResponse, *response.Pagination, error) {
+func (s *UserService) GetAll(ctx context.Context, actor policy.Actor, params *utils.PaginationParams) ([]schemas.UserResponse, *response.Pagination, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAll")
+	defer span.End()
+
+	if err := policy.CanListUsers(actor); err != nil {
+		return nil, nil, err
+	}
+
 	// set default value
 	params.GetDefaults()
 
 	// get users from repository
-	users, total, err := s.userRepo.GetAll(params)
+	users, pagination, err := s.findUsers(ctx, params)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -51,14 +111,18 @@ func (s *UserService) GetAll(params *utils.PaginationParams) ([]schemas.UserResp
 		userResponses = append(userResponses, schemas.UserToResponse(user))
 	}
 
-	// Calculate pagination
-	pagination := utils.CalculatePagination(params.Page, params.Size, total)
-
 	return userResponses, pagination, nil
 }
 
-func (s *UserService) GetByID(id uint) (*schemas.UserResponse, error) {
-	user, err := s.userRepo.GetByID(id)
+func (s *UserService) GetByID(ctx context.Context, actor policy.Actor, id uint) (*schemas.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetByID")
+	defer span.End()
+
+	if err := policy.CanViewUser(actor, id); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -67,49 +131,156 @@ func (s *UserService) GetByID(id uint) (*schemas.UserResponse, error) {
 	return &response, nil
 }
 
-func (s *UserService) Update(id uint, req *schemas.UpdateUserRequest) (*schemas.UserResponse, error) {
+// Update changes a user. A new role or password revokes the user's access tokens,
+// a new password also ends their sessions by revoking the refresh tokens. A new
+// email is unverified until the user confirms it from the email sent to it.
+func (s *UserService) Update(ctx context.Context, actor policy.Actor, id uint, req *schemas.UpdateUserRequest) (*schemas.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Update")
+	defer span.End()
+
+	if err := policy.CanUpdateUser(actor, id); err != nil {
+		return nil, err
+	}
+	if req.Password != "" {
+		if err := policy.CanSetPassword(actor, id); err != nil {
+			return nil, err
+		}
+	}
+
 	// Get user by id
-	user, err := s.userRepo.GetByID(id)
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
-		return nil, errors.New("User not found")
+		return nil, err
+	}
+
+	// sending back the current role is not a role change
+	roleChanged := req.Role != "" && models.IsValidRole(req.Role) && req.Role != user.Role
+	if roleChanged {
+		if err := policy.CanChangeRole(actor); err != nil {
+			return nil, err
+		}
 	}
 
+	// cached books embed the owner's name and email
+	emailChanged := req.Email != "" && req.Email != user.Email
+	ownerChanged := emailChanged || (req.Username != "" && req.Username != user.Name)
+
 	// update field if provide
-	if req.Email != "" {
+	if emailChanged {
 		user.Email = req.Email
+		user.EmailVerifiedAt = nil
 	}
 	if req.Username != "" {
 		user.Name = req.Username
 	}
-	if req.Role != "" && models.IsValidRole(req.Role) {
+	if roleChanged {
 		user.Role = req.Role
 	}
 	if req.Password != "" {
+		// checked after the email and name above so the new values count as personal info
+		if violations := s.passwordPolicy.Check("Password", req.Password, user.Email, user.Name); len(violations) > 0 {
+			return nil, ErrWeakPassword.WithDetails(violations)
+		}
+
 		hashPassword, err := utils.HashPassword(req.Password)
 		if err != nil {
-			return nil, err
+			return nil, apperror.Internal(fmt.Errorf("hash password: %w", err))
 		}
 		user.Password = hashPassword
 	}
 
-	// save to database
-	if err := s.userRepo.Update(id, user); err != nil {
-		return nil, errors.New("user update failed")
+	// save to database, a new password only together with ending the sessions
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Update(ctx, id, user); err != nil {
+			return err
+		}
+		if emailChanged {
+			if err := s.userRepo.ClearEmailVerified(ctx, id); err != nil {
+				return err
+			}
+		}
+		if req.Password == "" {
+			return nil
+		}
+		return s.refreshTokenRepo.RevokeAllForUser(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if ownerChanged {
+		s.bookCache.InvalidateCache(ctx)
+	}
+
+	// a failed email does not undo the change, the user can ask for a resend
+	if emailChanged {
+		if err := s.verifier.SendVerification(ctx, user); err != nil {
+			pkgLogger.FromContext(ctx).WithError(err).Error("send verification email")
+		}
+	}
+
+	// tokens carry the role, so old ones must not keep the previous permissions
+	if roleChanged || req.Password != "" {
+		if err := s.revokeSessions(ctx, id); err != nil {
+			return nil, err
+		}
 	}
 
 	response := schemas.UserToResponse(user)
 	return &response, nil
 }
 
-func (s *UserService) Delete(id uint) error {
-	_, err := s.userRepo.GetByID(id)
-	if err != nil {
-		return errors.New("User not found")
+func (s *UserService) Delete(ctx context.Context, actor policy.Actor, id uint) error {
+	ctx, span := tracing.Start(ctx, "UserService.Delete")
+	defer span.End()
+
+	if err := policy.CanDeleteUser(actor); err != nil {
+		return err
 	}
 
-	if err := s.userRepo.Delete(id); err != nil {
-		return errors.New("user delete failed")
+	// the user and their books go together or not at all
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.userRepo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		if err := s.bookRepo.DeleteByUserID(ctx, id); err != nil {
+			return err
+		}
+		return s.userRepo.Delete(ctx, id)
+	})
+	if err != nil {
+		return err
 	}
 
+	// the deleted books must not be served from the cache
+	s.bookCache.InvalidateCache(ctx)
+
+	return s.revokeSessions(ctx, id)
+}
+
+// revokeSessions rejects the user's access tokens after a committed change.
+// The change stays, so a failure is logged with the user for an operator to follow up.
+func (s *UserService) revokeSessions(ctx context.Context, id uint) error {
+	if err := s.revoker.RevokeUser(ctx, id); err != nil {
+		pkgLogger.FromContext(ctx).WithError(err).WithField("revoked_user_id", id).Error("revoke access tokens")
+		return apperror.Internal(fmt.Errorf("revoke access tokens: %w", err))
+	}
 	return nil
 }
+
+// findUsers runs the list query in the pagination mode the client asked for
+func (s *UserService) findUsers(ctx context.Context, params *utils.PaginationParams) ([]*models.User, *response.Pagination, error) {
+	if params.Keyset {
+		users, page, err := s.userRepo.GetAllKeyset(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		return users, utils.CalculateCursorPagination(params.Size, page), nil
+	}
+
+	users, total, err := s.userRepo.GetAll(ctx, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, utils.CalculatePagination(params.Page, params.Size, total), nil
+}
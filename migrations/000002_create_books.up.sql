@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS books (
+    id         BIGSERIAL PRIMARY KEY,
+    title      TEXT NOT NULL,
+    author     TEXT NOT NULL,
+    "desc"     TEXT,
+    user_id    BIGINT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMPTZ,
+    CONSTRAINT fk_books_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_books_deleted_at ON books (deleted_at);
@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+
+-- accounts created before verification existed stay usable
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
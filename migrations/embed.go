@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migrations so the server binary can apply them
+package migrations
+
+import "embed"
+
+// FS holds the numbered up/down SQL files of this directory
+//
+//go:embed *.sql
+var FS embed.FS
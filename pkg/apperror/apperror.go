@@ -0,0 +1,112 @@
+package apperror
+
+import (
+	"errors"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/response"
+)
+
+// Kind classifies an error, the global error handler maps it to an HTTP status
+type Kind string
+
+const (
+	KindValidation   Kind = "VALIDATION"
+	KindUnauthorized Kind = "UNAUTHORIZED"
+	KindForbidden    Kind = "FORBIDDEN"
+	KindNotFound     Kind = "NOT_FOUND"
+	KindConflict     Kind = "CONFLICT"
+	KindRateLimited  Kind = "RATE_LIMITED"
+	KindInternal     Kind = "INTERNAL"
+)
+
+// Error is a domain error returned by services and repositories.
+// Code is a stable machine-readable identifier such as "EMAIL_TAKEN".
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Details []response.ValidationError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches errors with the same kind and code, so package level
+// sentinels still match after Wrap
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e.Kind == t.Kind && e.Code == t.Code
+}
+
+// Wrap returns a copy of the error with err attached as the cause
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// WithDetails returns a copy of the error with field level details
+func (e *Error) WithDetails(details []response.ValidationError) *Error {
+	wrapped := *e
+	wrapped.Details = details
+	return &wrapped
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func Validation(code, message string) *Error {
+	return New(KindValidation, code, message)
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func RateLimited(code, message string) *Error {
+	return New(KindRateLimited, code, message)
+}
+
+// Internal hides the cause from clients behind a generic message
+func Internal(err error) *Error {
+	return &Error{Kind: KindInternal, Code: "INTERNAL_ERROR", Message: "Internal Server Error", Err: err}
+}
+
+// As extracts an *Error from err
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+// IsKind reports whether err is an *Error of the given kind
+func IsKind(err error, kind Kind) bool {
+	appErr, ok := As(err)
+	return ok && appErr.Kind == kind
+}
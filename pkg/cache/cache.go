@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMiss is returned by Get when the key is not cached
+var ErrMiss = errors.New("cache: miss")
+
+// Store is a key/value cache backend
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// Status tells clients whether a response was served from cache
+type Status string
+
+const (
+	StatusHit    Status = "HIT"
+	StatusMiss   Status = "MISS"
+	StatusBypass Status = "BYPASS"
+)
+
+// Header is the response header carrying the cache Status
+const Header = "X-Cache"
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryStore is an in-process Store, for tests and single instance deployments
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries map[string]memoryEntry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry)}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.RLock()
+	entry, ok := s.entries[key]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrMiss
+	}
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		s.mu.Lock()
+		delete(s.entries, key)
+		s.mu.Unlock()
+		return nil, ErrMiss
+	}
+
+	return entry.value, nil
+}
+
+// Set stores value, a zero ttl never expires
+func (s *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	s.mu.Lock()
+	s.entries[key] = entry
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	for _, key := range keys {
+		delete(s.entries, key)
+	}
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *MemoryStore) DeletePrefix(ctx context.Context, prefix string) error {
+	s.mu.Lock()
+	for key := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.entries, key)
+		}
+	}
+	s.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreGetSet(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	if _, err := store.Get(ctx, "books:1"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get() before Set error = %v, want ErrMiss", err)
+	}
+
+	if err := store.Set(ctx, "books:1", []byte("dune"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.Get(ctx, "books:1"); err != nil || string(got) != "dune" {
+		t.Errorf("Get() = %q, %v, want dune", got, err)
+	}
+
+	// a second Set replaces the value
+	if err := store.Set(ctx, "books:1", []byte("emma"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.Get(ctx, "books:1"); err != nil || string(got) != "emma" {
+		t.Errorf("Get() after overwrite = %q, %v, want emma", got, err)
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	if err := store.Set(ctx, "expired", []byte("x"), time.Nanosecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(ctx, "forever", []byte("x"), 0); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+
+	if _, err := store.Get(ctx, "expired"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get() after ttl error = %v, want ErrMiss", err)
+	}
+	if _, err := store.Get(ctx, "forever"); err != nil {
+		t.Errorf("Get() with zero ttl error = %v, want the value", err)
+	}
+}
+
+func TestMemoryStoreDelete(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		delete   func(store *MemoryStore) error
+		wantKeys []string
+	}{
+		{
+			name:     "keys",
+			delete:   func(store *MemoryStore) error { return store.Delete(ctx, "books:1", "books:missing") },
+			wantKeys: []string{"books:2", "books:list:a", "books:list:b", "users:1"},
+		},
+		{
+			name:     "no keys",
+			delete:   func(store *MemoryStore) error { return store.Delete(ctx) },
+			wantKeys: []string{"books:1", "books:2", "books:list:a", "books:list:b", "users:1"},
+		},
+		{
+			name:     "prefix",
+			delete:   func(store *MemoryStore) error { return store.DeletePrefix(ctx, "books:list:") },
+			wantKeys: []string{"books:1", "books:2", "users:1"},
+		},
+		{
+			name:     "wider prefix",
+			delete:   func(store *MemoryStore) error { return store.DeletePrefix(ctx, "books:") },
+			wantKeys: []string{"users:1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			all := []string{"books:1", "books:2", "books:list:a", "books:list:b", "users:1"}
+			for _, key := range all {
+				if err := store.Set(ctx, key, []byte(key), time.Minute); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := tt.delete(store); err != nil {
+				t.Fatal(err)
+			}
+
+			want := make(map[string]bool)
+			for _, key := range tt.wantKeys {
+				want[key] = true
+			}
+			for _, key := range all {
+				_, err := store.Get(ctx, key)
+				if got := err == nil; got != want[key] {
+					t.Errorf("Get(%s) cached = %v, want %v", key, got, want[key])
+				}
+			}
+		})
+	}
+}
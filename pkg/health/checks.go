@@ -0,0 +1,21 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"github.com/redis/go-redis/v9"
+)
+
+// SQLCheck pings a database connection pool
+func SQLCheck(db *sql.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// RedisCheck pings a Redis server
+func RedisCheck(client *redis.Client) CheckFunc {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}
+}
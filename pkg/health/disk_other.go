@@ -0,0 +1,12 @@
+//go:build !unix
+
+package health
+
+import "context"
+
+// DiskCheck is not supported on this platform and always passes
+func DiskCheck(path string, minFreeBytes uint64) CheckFunc {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
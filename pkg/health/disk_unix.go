@@ -0,0 +1,25 @@
+//go:build unix
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+)
+
+// DiskCheck fails when the filesystem holding path has less than minFreeBytes available
+func DiskCheck(path string, minFreeBytes uint64) CheckFunc {
+	return func(ctx context.Context) error {
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(path, &stat); err != nil {
+			return err
+		}
+
+		free := stat.Bavail * uint64(stat.Bsize)
+		if free < minFreeBytes {
+			return fmt.Errorf("only %d MB free on %s", free/1024/1024, path)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,120 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Status of a single check or of the whole report
+type Status string
+
+const (
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// CheckFunc returns nil when the dependency is healthy
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of one check
+type CheckResult struct {
+	Status    Status  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of all checks. A failing critical check makes the
+// report down, a failing non-critical check only degrades it.
+type Report struct {
+	Status    Status                 `json:"status"`
+	Timestamp time.Time              `json:"timestamp"`
+	Checks    map[string]CheckResult `json:"checks"`
+}
+
+type registeredCheck struct {
+	name     string
+	check    CheckFunc
+	critical bool
+}
+
+// Registry holds the dependency checks run for readiness
+type Registry struct {
+	mu      sync.RWMutex
+	checks  []registeredCheck
+	timeout time.Duration
+}
+
+// NewRegistry creates a Registry, each check is cancelled after timeout
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{timeout: timeout}
+}
+
+// Register adds a check, critical checks decide whether the service is ready
+func (r *Registry) Register(name string, check CheckFunc, critical bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks = append(r.checks, registeredCheck{name: name, check: check, critical: critical})
+}
+
+// Run executes all checks concurrently
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make([]registeredCheck, len(r.checks))
+	copy(checks, r.checks)
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c registeredCheck) {
+			defer wg.Done()
+			results[i] = r.runCheck(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:    StatusUp,
+		Timestamp: time.Now().UTC(),
+		Checks:    make(map[string]CheckResult, len(checks)),
+	}
+	for i, c := range checks {
+		result := results[i]
+		report.Checks[c.name] = result
+
+		if result.Status == StatusUp {
+			continue
+		}
+		if c.critical {
+			report.Status = StatusDown
+		} else if report.Status == StatusUp {
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+func (r *Registry) runCheck(ctx context.Context, c registeredCheck) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.check(ctx)
+	result := CheckResult{
+		Status:    StatusUp,
+		Critical:  c.critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -1,44 +1,139 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v4"
 	"time"
 )
 
+var (
+	ErrUnknownKey       = errors.New("token signed with an unknown key")
+	ErrInvalidIssuer    = errors.New("token has an invalid issuer")
+	ErrInvalidAudience  = errors.New("token has an invalid audience")
+	ErrNoSigningKey     = errors.New("key ring has no signing key")
+	ErrUnsupportedKey   = errors.New("unsupported key type, use RSA or Ed25519")
+	ErrKeyTooWeak       = errors.New("RSA keys must be at least 2048 bits")
+	ErrInvalidKeyFormat = errors.New("invalid PEM key")
+)
+
 type Claims struct {
 	UserID uint   `json:"user_id"`
 	Email  string `json:"email"`
 	Role   string `json:"role"`
+
+	// IssuedAtMs is the issue time in Unix milliseconds. It is compared with
+	// revocation times, where the whole seconds of iat would let a token issued
+	// just before a revocation through or reject one issued just after.
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
+
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID uint, email, role, secret string) (string, error) {
+// IssuedAtTime returns the issue time, falling back to iat for tokens without iat_ms
+func (c *Claims) IssuedAtTime() time.Time {
+	if c.IssuedAtMs > 0 {
+		return time.UnixMilli(c.IssuedAtMs)
+	}
+	if c.IssuedAt != nil {
+		return c.IssuedAt.Time
+	}
+	return time.Time{}
+}
+
+// GenerateToken signs an access token valid for ttl with the active key and returns it with its expiry
+func (r *KeyRing) GenerateToken(userID uint, email, role string, ttl time.Duration) (string, time.Time, error) {
+	if r.active == nil {
+		return "", time.Time{}, ErrNoSigningKey
+	}
+
+	jti, err := newTokenID()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
 	claims := Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+		UserID:     userID,
+		Email:      email,
+		Role:       role,
+		IssuedAtMs: now.UnixMilli(),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(1 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        jti,
+			Issuer:    r.issuer,
+			Audience:  jwt.ClaimStrings{r.audience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	token := jwt.NewWithClaims(r.active.method(), claims)
+	token.Header["kid"] = r.active.ID
+	signed, err := token.SignedString(r.active.private)
+	return signed, expiresAt, err
 }
 
-func ValidateToken(tokenString string, secret string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
-	})
+// ValidateToken verifies the signature with the key named by the kid header and
+// checks expiry, issuer and audience. The algorithm must be the one of that
+// key, so a token cannot pick a weaker algorithm or use a public key as an HMAC secret.
+func (r *KeyRing) ValidateToken(tokenString string) (*Claims, error) {
+	var key *Key
+	parser := jwt.NewParser(jwt.WithValidMethods(r.algorithms()))
 
+	token, err := parser.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key = r.keys[kid]
+		if key == nil {
+			return nil, ErrUnknownKey
+		}
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("token algorithm %s does not match key %s", token.Method.Alg(), kid)
+		}
+		return key.public, nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, jwt.ErrTokenUnverifiable
+	}
+	if !claims.VerifyIssuer(r.issuer, true) {
+		return nil, ErrInvalidIssuer
 	}
+	if !claims.VerifyAudience(r.audience, true) {
+		return nil, ErrInvalidAudience
+	}
+
+	return claims, nil
+}
+
+// newTokenID returns a random jti, the key for revoking a single token
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateOpaqueToken returns a random URL-safe token, used for refresh tokens
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
 
-	return nil, err
+// HashToken returns the SHA-256 hex digest stored in place of an opaque token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
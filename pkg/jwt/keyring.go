@@ -0,0 +1,206 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Supported signing algorithms
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// rsaKeyBits is the size of generated RSA keys
+const rsaKeyBits = 3072
+
+// Key is one key of a KeyRing. A key without a private part only verifies,
+// which keeps tokens signed by a retired key valid until they expire.
+type Key struct {
+	ID        string
+	Algorithm string
+
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// CanSign reports whether the private part of the key is available
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+func (k *Key) method() jwt.SigningMethod {
+	if k.Algorithm == AlgorithmEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// KeyRing signs access tokens with one active key and verifies tokens of every key it holds.
+// Rotation overlaps: a new key is added and published in the JWKS first, then made active,
+// and the old key is only removed once the tokens it signed have expired.
+type KeyRing struct {
+	keys     map[string]*Key
+	active   *Key
+	issuer   string
+	audience string
+}
+
+// NewKeyRing creates a KeyRing signing with the key activeID. An empty activeID
+// picks the signing key with the greatest ID, so IDs that sort by creation time
+// rotate by adding a newer key.
+func NewKeyRing(keys []*Key, activeID, issuer, audience string) (*KeyRing, error) {
+	ring := &KeyRing{keys: make(map[string]*Key, len(keys)), issuer: issuer, audience: audience}
+
+	for _, key := range keys {
+		if _, exists := ring.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		ring.keys[key.ID] = key
+
+		if activeID == "" && key.CanSign() && (ring.active == nil || key.ID > ring.active.ID) {
+			ring.active = key
+		}
+	}
+
+	if activeID != "" {
+		ring.active = ring.keys[activeID]
+		if ring.active == nil || !ring.active.CanSign() {
+			return nil, fmt.Errorf("active key %q not found or has no private key", activeID)
+		}
+	}
+	if ring.active == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	return ring, nil
+}
+
+// ActiveKeyID returns the kid new tokens are signed with
+func (r *KeyRing) ActiveKeyID() string {
+	return r.active.ID
+}
+
+// algorithms lists the algorithms of the keys in the ring
+func (r *KeyRing) algorithms() []string {
+	seen := map[string]bool{}
+	var algorithms []string
+	for _, key := range r.keys {
+		if !seen[key.Algorithm] {
+			seen[key.Algorithm] = true
+			algorithms = append(algorithms, key.Algorithm)
+		}
+	}
+	return algorithms
+}
+
+// LoadKeys reads every <kid>.pem file in dir. Private keys (PKCS#1 or PKCS#8)
+// sign and verify, public keys (PKIX) only verify.
+func LoadKeys(dir string) ([]*Key, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	keys := make([]*Key, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := ParseKeyPEM(strings.TrimSuffix(filepath.Base(path), ".pem"), data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// ParseKeyPEM parses an RSA or Ed25519 key, the algorithm follows from the key type
+func ParseKeyPEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidKeyFormat
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, ErrInvalidKeyFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newKey(id, parsed)
+}
+
+// GenerateKey creates a new private key for algorithm and returns it PEM encoded (PKCS#8)
+func GenerateKey(algorithm string) ([]byte, error) {
+	var private interface{}
+	var err error
+	switch algorithm {
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q, use %s or %s", algorithm, AlgorithmRS256, AlgorithmEdDSA)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// newKey wraps a parsed private or public key
+func newKey(id string, parsed interface{}) (*Key, error) {
+	if id == "" {
+		return nil, fmt.Errorf("key id is required")
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, ErrKeyTooWeak
+		}
+		return &Key{ID: id, Algorithm: AlgorithmRS256, private: k, public: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		if k.N.BitLen() < 2048 {
+			return nil, ErrKeyTooWeak
+		}
+		return &Key{ID: id, Algorithm: AlgorithmRS256, public: k}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Algorithm: AlgorithmEdDSA, private: k, public: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Algorithm: AlgorithmEdDSA, public: k}, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
@@ -0,0 +1,422 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/golang-jwt/jwt/v4"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newEdDSAKey(t *testing.T, id string) *Key {
+	t.Helper()
+	data, err := GenerateKey(AlgorithmEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParseKeyPEM(id, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newRSAKey(t *testing.T, id string, bits int) *Key {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParseKeyPEM(id, pemEncode(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(private)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// publicOnly returns the verify-only copy of key, as loaded from a PUBLIC KEY file
+func publicOnly(t *testing.T, key *Key) *Key {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key.public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := ParseKeyPEM(key.ID, pemEncode(t, "PUBLIC KEY", der))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return public
+}
+
+func pemEncode(t *testing.T, blockType string, der []byte) []byte {
+	t.Helper()
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+func newTestRing(t *testing.T, activeID string, keys ...*Key) *KeyRing {
+	t.Helper()
+	ring, err := NewKeyRing(keys, activeID, "test-issuer", "test-audience")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ring
+}
+
+func TestNewKeyRingActiveKey(t *testing.T) {
+	older := newEdDSAKey(t, "2024-01")
+	newer := newEdDSAKey(t, "2024-06")
+	newestPublic := publicOnly(t, newEdDSAKey(t, "2024-12"))
+
+	tests := []struct {
+		name     string
+		keys     []*Key
+		activeID string
+		want     string
+		wantErr  error
+	}{
+		{name: "greatest kid signs", keys: []*Key{newer, older}, want: "2024-06"},
+		{name: "public keys never sign", keys: []*Key{older, newer, newestPublic}, want: "2024-06"},
+		{name: "explicit active key", keys: []*Key{older, newer}, activeID: "2024-01", want: "2024-01"},
+		{name: "unknown active key", keys: []*Key{older}, activeID: "missing", wantErr: errors.New("not found")},
+		{name: "public active key", keys: []*Key{older, newestPublic}, activeID: "2024-12", wantErr: errors.New("no private key")},
+		{name: "no signing key", keys: []*Key{newestPublic}, wantErr: ErrNoSigningKey},
+		{name: "no keys", wantErr: ErrNoSigningKey},
+		{name: "duplicate kid", keys: []*Key{older, older}, wantErr: errors.New("duplicate key id")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := NewKeyRing(tt.keys, tt.activeID, "test-issuer", "test-audience")
+			if tt.wantErr != nil {
+				if err == nil || (!errors.Is(err, tt.wantErr) && !strings.Contains(err.Error(), tt.wantErr.Error())) {
+					t.Fatalf("NewKeyRing() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ring.ActiveKeyID(); got != tt.want {
+				t.Errorf("ActiveKeyID() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyRingGenerateAndValidate(t *testing.T) {
+	for _, key := range []*Key{newEdDSAKey(t, "ed"), newRSAKey(t, "rsa", 2048)} {
+		t.Run(key.Algorithm, func(t *testing.T) {
+			ring := newTestRing(t, "", key)
+
+			token, expiresAt, err := ring.GenerateToken(7, "jane@example.com", "ADMIN", time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.Header["kid"] != key.ID || parsed.Header["alg"] != key.Algorithm {
+				t.Errorf("header = %v, want kid %s and alg %s", parsed.Header, key.ID, key.Algorithm)
+			}
+
+			claims, err := ring.ValidateToken(token)
+			if err != nil {
+				t.Fatalf("ValidateToken() error = %v", err)
+			}
+			if claims.UserID != 7 || claims.Email != "jane@example.com" || claims.Role != "ADMIN" {
+				t.Errorf("claims = %+v", claims)
+			}
+			if claims.ID == "" {
+				t.Error("token has no jti")
+			}
+			if !claims.ExpiresAt.Time.Equal(expiresAt.Truncate(time.Second)) {
+				t.Errorf("exp = %v, want %v", claims.ExpiresAt.Time, expiresAt)
+			}
+			if got := claims.IssuedAtTime(); time.Since(got) > time.Minute || got.UnixMilli() != claims.IssuedAtMs {
+				t.Errorf("IssuedAtTime() = %v, iat_ms = %d", got, claims.IssuedAtMs)
+			}
+		})
+	}
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	old := newEdDSAKey(t, "2024-01")
+	token, _, err := newTestRing(t, "", old).GenerateToken(7, "jane@example.com", "USER", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the retired key only verifies, tokens it signed stay valid until they expire
+	rotated := newTestRing(t, "", publicOnly(t, old), newRSAKey(t, "2024-06", 2048))
+	if rotated.ActiveKeyID() != "2024-06" {
+		t.Fatalf("ActiveKeyID() = %s, want 2024-06", rotated.ActiveKeyID())
+	}
+	if _, err := rotated.ValidateToken(token); err != nil {
+		t.Errorf("ValidateToken() with the retired key error = %v", err)
+	}
+
+	// once the retired key is removed its tokens are rejected
+	removed := newTestRing(t, "", newEdDSAKey(t, "2024-06"))
+	if _, err := removed.ValidateToken(token); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("ValidateToken() after removal error = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestKeyRingValidateRejects(t *testing.T) {
+	edKey := newEdDSAKey(t, "ed")
+	rsaKey := newRSAKey(t, "rsa", 2048)
+	ring := newTestRing(t, "ed", edKey, rsaKey)
+
+	claims := func() Claims {
+		now := time.Now()
+		return Claims{
+			UserID: 7,
+			Role:   "ADMIN",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    "test-issuer",
+				Audience:  jwt.ClaimStrings{"test-audience"},
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			},
+		}
+	}
+	sign := func(method jwt.SigningMethod, kid string, claims Claims, key interface{}) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	edPublic, err := x509.MarshalPKIXPublicKey(edKey.public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := claims()
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	otherIssuer := claims()
+	otherIssuer.Issuer = "someone-else"
+	otherAudience := claims()
+	otherAudience.Audience = jwt.ClaimStrings{"another-service"}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid", sign(jwt.SigningMethodEdDSA, "ed", claims(), edKey.private), nil},
+		{"alg does not match kid", sign(jwt.SigningMethodRS256, "ed", claims(), rsaKey.private), nil},
+		{"HS256 with the public key as secret", sign(jwt.SigningMethodHS256, "ed", claims(), edPublic), nil},
+		{"alg none", sign(jwt.SigningMethodNone, "ed", claims(), jwt.UnsafeAllowNoneSignatureType), nil},
+		{"unknown kid", sign(jwt.SigningMethodEdDSA, "missing", claims(), edKey.private), ErrUnknownKey},
+		{"missing kid", sign(jwt.SigningMethodEdDSA, "", claims(), edKey.private), ErrUnknownKey},
+		{"signed by a foreign key", sign(jwt.SigningMethodEdDSA, "ed", claims(), newEdDSAKey(t, "ed").private), nil},
+		{"expired", sign(jwt.SigningMethodEdDSA, "ed", expired, edKey.private), nil},
+		{"other issuer", sign(jwt.SigningMethodEdDSA, "ed", otherIssuer, edKey.private), ErrInvalidIssuer},
+		{"other audience", sign(jwt.SigningMethodEdDSA, "ed", otherAudience, edKey.private), ErrInvalidAudience},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ring.ValidateToken(tt.token)
+			if tt.name == "valid" {
+				if err != nil {
+					t.Fatalf("ValidateToken() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("ValidateToken() accepted the token")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseKeyPEM(t *testing.T) {
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weakPKCS8, err := x509.MarshalPKCS8PrivateKey(weak)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weakPublic, err := x509.MarshalPKIXPublicKey(&weak.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPKCS8, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		id            string
+		data          []byte
+		wantAlgorithm string
+		wantSign      bool
+		wantErr       error
+	}{
+		{name: "Ed25519 PKCS#8", id: "ed", data: pemEncode(t, "PRIVATE KEY", edPKCS8), wantAlgorithm: AlgorithmEdDSA, wantSign: true},
+		{name: "Ed25519 public", id: "ed", data: pemEncode(t, "PUBLIC KEY", mustPKIX(t, edPrivate.Public())), wantAlgorithm: AlgorithmEdDSA},
+		{name: "RSA 1024 PKCS#1", id: "rsa", data: pemEncode(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(weak)), wantErr: ErrKeyTooWeak},
+		{name: "RSA 1024 PKCS#8", id: "rsa", data: pemEncode(t, "PRIVATE KEY", weakPKCS8), wantErr: ErrKeyTooWeak},
+		{name: "RSA 1024 public", id: "rsa", data: pemEncode(t, "PUBLIC KEY", weakPublic), wantErr: ErrKeyTooWeak},
+		{name: "not PEM", id: "x", data: []byte("not a key"), wantErr: ErrInvalidKeyFormat},
+		{name: "unknown block", id: "x", data: pemEncode(t, "CERTIFICATE", []byte{1}), wantErr: ErrInvalidKeyFormat},
+		{name: "missing id", id: "", data: pemEncode(t, "PRIVATE KEY", edPKCS8), wantErr: errors.New("key id is required")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseKeyPEM(tt.id, tt.data)
+			if tt.wantErr != nil {
+				if err == nil || (!errors.Is(err, tt.wantErr) && err.Error() != tt.wantErr.Error()) {
+					t.Fatalf("ParseKeyPEM() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key.Algorithm != tt.wantAlgorithm || key.CanSign() != tt.wantSign {
+				t.Errorf("ParseKeyPEM() = %s signing %v, want %s signing %v", key.Algorithm, key.CanSign(), tt.wantAlgorithm, tt.wantSign)
+			}
+		})
+	}
+}
+
+func mustPKIX(t *testing.T, public interface{}) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestLoadKeys(t *testing.T) {
+	dir := t.TempDir()
+	for _, kid := range []string{"2024-06", "2024-01"} {
+		data, err := GenerateKey(AlgorithmEdDSA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.txt"), []byte("ignored"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := LoadKeys(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0].ID != "2024-01" || keys[1].ID != "2024-06" {
+		t.Fatalf("LoadKeys() = %d keys, want 2024-01 and 2024-06", len(keys))
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.pem"), []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadKeys(dir); !errors.Is(err, ErrInvalidKeyFormat) {
+		t.Errorf("LoadKeys() with a broken file error = %v, want ErrInvalidKeyFormat", err)
+	}
+}
+
+func TestGenerateKeyRejectsUnknownAlgorithm(t *testing.T) {
+	if _, err := GenerateKey("HS256"); err == nil {
+		t.Error("GenerateKey(HS256) error = nil, want error")
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	edKey := newEdDSAKey(t, "b-ed")
+	rsaKey := newRSAKey(t, "a-rsa", 2048)
+	ring := newTestRing(t, "", edKey, publicOnly(t, rsaKey))
+
+	set := ring.JWKS()
+	if len(set.Keys) != 2 || set.Keys[0].KeyID != "a-rsa" || set.Keys[1].KeyID != "b-ed" {
+		t.Fatalf("JWKS() = %+v, want a-rsa and b-ed sorted by kid", set.Keys)
+	}
+
+	rsaJWK := set.Keys[0]
+	if rsaJWK.KeyType != "RSA" || rsaJWK.Algorithm != AlgorithmRS256 || rsaJWK.Use != "sig" {
+		t.Errorf("RSA JWK = %+v", rsaJWK)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(rsaJWK.N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(rsaJWK.E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public := rsaKey.public.(*rsa.PublicKey)
+	if new(big.Int).SetBytes(n).Cmp(public.N) != 0 || int(new(big.Int).SetBytes(e).Int64()) != public.E {
+		t.Error("RSA JWK n and e do not match the public key")
+	}
+	if rsaJWK.E != "AQAB" {
+		t.Errorf("RSA JWK e = %s, want AQAB", rsaJWK.E)
+	}
+
+	edJWK := set.Keys[1]
+	if edJWK.KeyType != "OKP" || edJWK.Curve != "Ed25519" || edJWK.Algorithm != AlgorithmEdDSA || edJWK.Use != "sig" {
+		t.Errorf("Ed25519 JWK = %+v", edJWK)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(edJWK.X)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.PublicKey(x).Equal(edKey.public) {
+		t.Error("Ed25519 JWK x does not match the public key")
+	}
+
+	// only public parts are published
+	raw, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var generic struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		t.Fatal(err)
+	}
+	for _, jwk := range generic.Keys {
+		for _, private := range []string{"d", "p", "q", "dp", "dq", "qi"} {
+			if _, ok := jwk[private]; ok {
+				t.Errorf("JWK %v contains private member %q", jwk["kid"], private)
+			}
+		}
+		if _, ok := jwk["crv"]; ok && jwk["kty"] == "RSA" {
+			t.Errorf("RSA JWK has Ed25519 members: %v", jwk)
+		}
+	}
+}
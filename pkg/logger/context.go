@@ -0,0 +1,22 @@
+package logger
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+)
+
+type ctxKey struct{}
+
+// FromContext returns the request scoped logger stored in ctx,
+// or the global logger when ctx carries none
+func FromContext(ctx context.Context) *logrus.Entry {
+	if entry, ok := ctx.Value(ctxKey{}).(*logrus.Entry); ok {
+		return entry
+	}
+	return logrus.NewEntry(Log)
+}
+
+// WithFields returns a copy of ctx whose logger also carries fields
+func WithFields(ctx context.Context, fields Fields) context.Context {
+	return context.WithValue(ctx, ctxKey{}, FromContext(ctx).WithFields(fields))
+}
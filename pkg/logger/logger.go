@@ -1,17 +1,36 @@
 package logger
 
 import (
+	"fmt"
 	"github.com/sirupsen/logrus"
 	"os"
+	"strings"
 )
 
-var Log *logrus.Logger
+// Fields is a set of structured log fields
+type Fields = logrus.Fields
 
-func Init() {
-	Log = logrus.New()
-	Log.SetOutput(os.Stdout)
-	Log.SetLevel(logrus.InfoLevel)
-	Log.SetFormatter(&logrus.JSONFormatter{})
+// Log is the process wide logger, usable before Init with the defaults below
+var Log = newLogger()
+
+// Init configures the level ("debug", "info", ...) and the format ("json" or "text")
+func Init(level, format string) error {
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	Log.SetLevel(parsed)
+
+	switch strings.ToLower(format) {
+	case "json":
+		Log.SetFormatter(&logrus.JSONFormatter{})
+	case "text":
+		Log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+
+	return nil
 }
 
 func Info(msg string) {
@@ -21,3 +40,12 @@ func Info(msg string) {
 func Error(msg string) {
 	Log.Error(msg)
 }
+
+func newLogger() *logrus.Logger {
+	log := logrus.New()
+	log.SetOutput(os.Stdout)
+	log.SetLevel(logrus.InfoLevel)
+	log.SetFormatter(&logrus.JSONFormatter{})
+	log.AddHook(redactHook{})
+	return log
+}
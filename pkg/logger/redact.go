@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"github.com/sirupsen/logrus"
+	"strings"
+)
+
+// Redacted replaces the value of sensitive fields
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are matched as substrings of lowercased field names,
+// e.g. "password" also covers "new_password"
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "cookie"}
+
+// redactHook masks sensitive fields, including inside nested maps, before any entry is written
+type redactHook struct{}
+
+func (redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (redactHook) Fire(entry *logrus.Entry) error {
+	entry.Data = Redact(entry.Data)
+	return nil
+}
+
+// Redact returns a copy of fields with sensitive values masked
+func Redact(fields map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		switch {
+		case isSensitive(key):
+			redacted[key] = Redacted
+		case isNestedFields(value):
+			redacted[key] = Redact(asFields(value))
+		default:
+			redacted[key] = value
+		}
+	}
+	return redacted
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNestedFields(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, logrus.Fields:
+		return true
+	}
+	return false
+}
+
+func asFields(value interface{}) map[string]interface{} {
+	if fields, ok := value.(logrus.Fields); ok {
+		return fields
+	}
+	return value.(map[string]interface{})
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics owns the Prometheus registry and the application collectors
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpDuration *prometheus.HistogramVec
+	dbDuration   *prometheus.HistogramVec
+	dbQueries    *prometheus.CounterVec
+	authAttempts *prometheus.CounterVec
+}
+
+// New creates the collectors and registers them with Go runtime and process metrics
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method, route template and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		dbDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "GORM query latency by operation and table.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table"}),
+		dbQueries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "db_queries_total",
+			Help: "GORM queries by operation, table and result.",
+		}, []string{"operation", "table", "result"}),
+		authAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_attempts_total",
+			Help: "Authentication attempts by action and result.",
+		}, []string{"action", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpDuration,
+		m.dbDuration,
+		m.dbQueries,
+		m.authAttempts,
+	)
+
+	return m
+}
+
+// Handler serves the registry in the Prometheus text exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDBStats exposes the connection pool stats of db
+func (m *Metrics) RegisterDBStats(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveHTTP records one HTTP request, route is the template such as /books/:id
+func (m *Metrics) ObserveHTTP(method, route string, status int, duration time.Duration) {
+	m.httpDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// ObserveQuery records one database query
+func (m *Metrics) ObserveQuery(operation, table string, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	m.dbDuration.WithLabelValues(operation, table).Observe(duration.Seconds())
+	m.dbQueries.WithLabelValues(operation, table, result).Inc()
+}
+
+// AuthAttempt counts one authentication attempt such as a login or a refresh
+func (m *Metrics) AuthAttempt(action string, success bool) {
+	result := "success"
+	if !success {
+		result = "failure"
+	}
+	m.authAttempts.WithLabelValues(action, result).Inc()
+}
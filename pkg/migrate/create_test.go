@@ -0,0 +1,44 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateNumbersAfterHighestVersion(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"000001_create_users.up.sql", "000007_create_books.up.sql", "000007_create_books.down.sql", "000099_notes.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("-- "+name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	up, down, err := Create(dir, "Add Book ISBN!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "000008_add_book_isbn.up.sql"); up != want {
+		t.Errorf("Create() up = %s, want %s", up, want)
+	}
+	if want := filepath.Join(dir, "000008_add_book_isbn.down.sql"); down != want {
+		t.Errorf("Create() down = %s, want %s", down, want)
+	}
+
+	// the new pair loads as a migration
+	migrations, err := New(nil, os.DirFS(dir)).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := migrations[len(migrations)-1]; last.Version != 8 || last.Name != "add_book_isbn" {
+		t.Errorf("Load() last = %d_%s, want 8_add_book_isbn", last.Version, last.Name)
+	}
+}
+
+func TestCreateRejectsEmptyName(t *testing.T) {
+	for _, name := range []string{"", "!!!", "___"} {
+		if _, _, err := Create(t.TempDir(), name); err == nil {
+			t.Errorf("Create(%q) error = nil", name)
+		}
+	}
+}
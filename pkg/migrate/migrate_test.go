@@ -0,0 +1,146 @@
+package migrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func file(content string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(content)}
+}
+
+func TestLoadOrdersByVersion(t *testing.T) {
+	source := fstest.MapFS{
+		"000010_add_index.up.sql":      file("CREATE INDEX i ON books (title);"),
+		"000010_add_index.down.sql":    file("DROP INDEX i;"),
+		"000002_create_books.up.sql":   file("CREATE TABLE books ();"),
+		"000002_create_books.down.sql": file("DROP TABLE books;"),
+		"000001_create_users.up.sql":   file("CREATE TABLE users ();"),
+		"000003_seed.up.sql":           file("INSERT INTO books DEFAULT VALUES;"),
+	}
+
+	migrations, err := New(nil, source).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, migration := range migrations {
+		got = append(got, migration.Name)
+	}
+	if want := "create_users,create_books,seed,add_index"; strings.Join(got, ",") != want {
+		t.Fatalf("Load() order = %v, want %s", got, want)
+	}
+
+	books := migrations[1]
+	if books.Version != 2 || books.Up != "CREATE TABLE books ();" || books.Down != "DROP TABLE books;" {
+		t.Errorf("Load() books = %+v", books)
+	}
+	if books.Checksum != checksum([]byte("CREATE TABLE books ();")) {
+		t.Errorf("Load() checksum = %s, want the checksum of the up script", books.Checksum)
+	}
+	if migrations[0].Down != "" {
+		t.Errorf("Load() down without a file = %q, want empty", migrations[0].Down)
+	}
+}
+
+func TestLoadIgnoresOtherFileNames(t *testing.T) {
+	source := fstest.MapFS{
+		"000001_create_users.up.sql": file("CREATE TABLE users ();"),
+		"README.md":                  file("# migrations"),
+		"embed.go":                   file("package migrations"),
+		"000002_Create_Books.up.sql": file("uppercase"),
+		"000003_books.sql":           file("no direction"),
+		"000004_books.sideways.sql":  file("unknown direction"),
+		"create_books.up.sql":        file("no version"),
+		"000005_books.up.sql.bak":    file("backup"),
+		"000006_sub/x.up.sql":        file("in a directory"),
+	}
+
+	migrations, err := New(nil, source).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 1 || migrations[0].Name != "create_users" {
+		t.Errorf("Load() = %+v, want only create_users", migrations)
+	}
+}
+
+func TestLoadRejectsInconsistentFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  fstest.MapFS
+		wantErr string
+	}{
+		{
+			name: "two names for one version",
+			source: fstest.MapFS{
+				"000001_create_users.up.sql":  file("CREATE TABLE users ();"),
+				"000001_create_people.up.sql": file("CREATE TABLE people ();"),
+			},
+			wantErr: "version 1 has two names",
+		},
+		{
+			name: "down without up",
+			source: fstest.MapFS{
+				"000001_create_users.down.sql": file("DROP TABLE users;"),
+			},
+			wantErr: "version 1 has no up script",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(nil, tt.source).Load(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "create_users", Checksum: checksum([]byte("CREATE TABLE users ();"))},
+		{Version: 2, Name: "create_books", Checksum: checksum([]byte("CREATE TABLE books ();"))},
+	}
+	applied := func(records map[int64]string) map[int64]appliedRecord {
+		result := make(map[int64]appliedRecord)
+		for version, content := range records {
+			result[version] = appliedRecord{checksum: checksum([]byte(content)), appliedAt: time.Now()}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name         string
+		applied      map[int64]appliedRecord
+		wantMismatch bool
+		wantErr      string
+	}{
+		{name: "nothing applied", applied: applied(nil)},
+		{name: "applied unchanged", applied: applied(map[int64]string{1: "CREATE TABLE users ();"})},
+		{name: "applied file edited", applied: applied(map[int64]string{1: "CREATE TABLE users (id INT);"}), wantMismatch: true, wantErr: "1_create_users"},
+		{name: "applied file removed", applied: applied(map[int64]string{1: "CREATE TABLE users ();", 3: "DROP TABLE books;"}), wantErr: "applied version 3 has no migration file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verify(migrations, tt.applied)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("verify() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("verify() error = %v, want %q", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrChecksumMismatch) != tt.wantMismatch {
+				t.Errorf("verify() error = %v, ErrChecksumMismatch = %v", err, tt.wantMismatch)
+			}
+		})
+	}
+}
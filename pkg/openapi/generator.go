@@ -0,0 +1,270 @@
+package openapi
+
+import (
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/response"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	jsonContent    = "application/json"
+	bearerSecurity = "bearerAuth"
+)
+
+// Route documents one entry of the route table
+type Route struct {
+	Summary string
+	Tags    []string
+
+	// Auth marks routes behind the bearer token middleware
+	Auth bool
+
+	// Request is the JSON body, Response the data inside the response
+	// envelope. Either may be nil.
+	Request  interface{}
+	Response interface{}
+
+	// Paginated routes return a list of Response with pagination metadata,
+	// Raw routes return Response as is without the envelope
+	Paginated bool
+	Raw       bool
+	Query     *utils.QuerySpec
+
+	// Status is the success status, 200 when zero. Errors lists error statuses
+	// besides the ones inferred from the route.
+	Status int
+	Errors []int
+
+	// Hidden routes are left out of the document
+	Hidden bool
+}
+
+// Key identifies a route in the docs map, path uses Fiber syntax such as /books/:id
+func Key(method, path string) string {
+	return method + " " + path
+}
+
+// Generator builds an OpenAPI document from the Fiber route table
+type Generator struct {
+	doc *Document
+}
+
+// NewGenerator creates a Generator for an API described by info
+func NewGenerator(info Info) *Generator {
+	return &Generator{doc: &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]*SecurityScheme{
+				bearerSecurity: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}}
+}
+
+// Build adds an operation for every route. Routes without an entry in docs
+// are still listed, with a generic response.
+func (g *Generator) Build(routes []fiber.Route, docs map[string]Route) *Document {
+	g.doc.Components.Schemas["ErrorResponse"] = &Schema{AllOf: []*Schema{
+		g.SchemaOf(response.BaseResponse{}),
+		{Type: "object", Required: []string{"error"}},
+	}}
+
+	tags := map[string]bool{}
+	for _, route := range routes {
+		if route.Method == fiber.MethodHead || route.Method == fiber.MethodOptions {
+			continue
+		}
+
+		doc := docs[Key(route.Method, route.Path)]
+		if doc.Hidden {
+			continue
+		}
+		for _, tag := range doc.Tags {
+			tags[tag] = true
+		}
+
+		path := openAPIPath(route.Path)
+		item, ok := g.doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			g.doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(route.Method)] = g.operation(route, doc)
+	}
+
+	g.doc.Tags = make([]Tag, 0, len(tags))
+	for tag := range tags {
+		g.doc.Tags = append(g.doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(g.doc.Tags, func(i, j int) bool { return g.doc.Tags[i].Name < g.doc.Tags[j].Name })
+
+	return g.doc
+}
+
+func (g *Generator) operation(route fiber.Route, doc Route) *Operation {
+	op := &Operation{
+		Summary:     doc.Summary,
+		OperationID: operationID(route.Method, route.Path),
+		Tags:        doc.Tags,
+		Responses:   map[string]*Response{},
+	}
+
+	for _, param := range route.Params {
+		op.Parameters = append(op.Parameters, pathParameter(param))
+	}
+	if doc.Query != nil {
+		op.Parameters = append(op.Parameters, queryParameters(*doc.Query)...)
+	}
+
+	if doc.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{jsonContent: {Schema: g.SchemaOf(doc.Request)}},
+		}
+	}
+
+	if doc.Auth {
+		op.Security = []map[string][]string{{bearerSecurity: {}}}
+	}
+
+	status := doc.Status
+	if status == 0 {
+		status = fiber.StatusOK
+	}
+	op.Responses[strconv.Itoa(status)] = &Response{
+		Description: http.StatusText(status),
+		Content:     map[string]MediaType{jsonContent: {Schema: g.successSchema(doc)}},
+	}
+
+	errorSchema := Ref("ErrorResponse")
+	if doc.Raw {
+		errorSchema = g.successSchema(doc)
+	}
+	for _, status := range errorStatuses(route, doc) {
+		op.Responses[strconv.Itoa(status)] = &Response{
+			Description: http.StatusText(status),
+			Content:     map[string]MediaType{jsonContent: {Schema: errorSchema}},
+		}
+	}
+
+	return op
+}
+
+// successSchema wraps the documented data in response.BaseResponse or response.PaginatedResponse
+func (g *Generator) successSchema(doc Route) *Schema {
+	if doc.Raw {
+		return g.SchemaOf(doc.Response)
+	}
+	if doc.Response == nil {
+		return g.SchemaOf(response.BaseResponse{})
+	}
+
+	data := g.SchemaOf(doc.Response)
+	envelope := g.SchemaOf(response.BaseResponse{})
+	if doc.Paginated {
+		data = &Schema{Type: "array", Items: data}
+		envelope = g.SchemaOf(response.PaginatedResponse{})
+	}
+
+	return &Schema{AllOf: []*Schema{
+		envelope,
+		{Type: "object", Properties: map[string]*Schema{"data": data}},
+	}}
+}
+
+// errorStatuses infers the error responses a route can return
+func errorStatuses(route fiber.Route, doc Route) []int {
+	statuses := map[int]bool{fiber.StatusInternalServerError: true}
+	if doc.Request != nil || doc.Query != nil || len(route.Params) > 0 {
+		statuses[fiber.StatusBadRequest] = true
+	}
+	if doc.Auth {
+		statuses[fiber.StatusUnauthorized] = true
+	}
+	if len(route.Params) > 0 {
+		statuses[fiber.StatusNotFound] = true
+	}
+	for _, status := range doc.Errors {
+		statuses[status] = true
+	}
+
+	sorted := make([]int, 0, len(statuses))
+	for status := range statuses {
+		sorted = append(sorted, status)
+	}
+	sort.Ints(sorted)
+	return sorted
+}
+
+// pathParameter documents a path parameter, ids are integers and everything else a string
+func pathParameter(name string) Parameter {
+	schema := &Schema{Type: "string"}
+	if name == "id" || strings.HasSuffix(name, "_id") {
+		schema = &Schema{Type: "integer", Minimum: float(1)}
+	}
+	return Parameter{Name: name, In: "path", Required: true, Schema: schema}
+}
+
+// queryParameters documents the list parameters parsed by utils.NewPaginationParams
+func queryParameters(spec utils.QuerySpec) []Parameter {
+	var sortable, filterable []string
+	for name, field := range spec.Fields {
+		if field.Sortable {
+			sortable = append(sortable, name)
+		}
+		if field.Filterable {
+			filterable = append(filterable, name)
+		}
+	}
+	sort.Strings(sortable)
+	sort.Strings(filterable)
+
+	params := []Parameter{
+		{Name: "page", In: "query", Description: "Page number for offset pagination", Schema: &Schema{Type: "integer", Minimum: float(1)}},
+		{Name: "size", In: "query", Description: "Page size", Schema: &Schema{Type: "integer", Minimum: float(1), Maximum: float(100)}},
+		{Name: "sort", In: "query", Description: "Comma separated fields, prefix with - for descending. One of: " + strings.Join(sortable, ", "), Schema: &Schema{Type: "string"}},
+		{Name: "order", In: "query", Description: "Direction of a single sort field", Schema: &Schema{Type: "string", Enum: []string{"asc", "desc"}}},
+		{Name: "search", In: "query", Description: "Free text search", Schema: &Schema{Type: "string"}},
+		{Name: "pagination", In: "query", Description: "Pagination mode", Schema: &Schema{Type: "string", Enum: []string{"offset", "cursor"}}},
+		{Name: "cursor", In: "query", Description: "Cursor from a previous page, implies cursor pagination", Schema: &Schema{Type: "string"}},
+	}
+	for _, name := range filterable {
+		params = append(params, Parameter{
+			Name:        name,
+			In:          "query",
+			Description: "Filter as value or op:value, op is one of eq, ne, gt, gte, lt, lte, like, in",
+			Schema:      &Schema{Type: "string"},
+		})
+	}
+	return params
+}
+
+// openAPIPath converts /books/:id to /books/{id}
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + strings.TrimSuffix(segment[1:], "?") + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// operationID derives a stable id such as get_api_v1_books_id
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.Trim(segment, ":?")
+		if segment != "" {
+			id += "_" + segment
+		}
+	}
+	return id
+}
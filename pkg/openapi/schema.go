@@ -0,0 +1,203 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaOf returns the schema of v's type. Named structs are added to
+// components once and referenced, validator tags become schema constraints.
+func (g *Generator) SchemaOf(v interface{}) *Schema {
+	return g.schemaFor(reflect.TypeOf(v))
+}
+
+func (g *Generator) schemaFor(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := g.schemaFor(t.Elem())
+		if schema.Ref != "" {
+			return schema
+		}
+		schema.Nullable = true
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: float(0)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		if _, ok := g.doc.Components.Schemas[t.Name()]; !ok {
+			// placeholder first so recursive types terminate
+			g.doc.Components.Schemas[t.Name()] = &Schema{}
+			g.doc.Components.Schemas[t.Name()] = g.structSchema(t)
+		}
+		return Ref(t.Name())
+	default:
+		// interface{} and anything else accept any value
+		return &Schema{}
+	}
+}
+
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, skip := jsonName(field)
+		if skip {
+			continue
+		}
+
+		// embedded structs without a json name are flattened like encoding/json does,
+		// fields of an embedded pointer are optional since a nil pointer omits them
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded := g.structSchema(field.Type)
+			for key, property := range embedded.Properties {
+				schema.Properties[key] = property
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct {
+			embedded := g.structSchema(field.Type.Elem())
+			for key, property := range embedded.Properties {
+				schema.Properties[key] = property
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := g.schemaFor(field.Type)
+		if applyValidateTag(property, field.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+
+	return schema
+}
+
+// jsonName returns the json field name, skip is true for `json:"-"`
+func jsonName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	return name, false
+}
+
+// applyValidateTag maps go-playground/validator rules onto schema constraints
+// and reports whether the field is required. Rules without an equivalent are ignored.
+func applyValidateTag(schema *Schema, tag string) bool {
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		if name == "required" {
+			required = true
+		}
+		if schema.Ref != "" {
+			// constraints cannot be added next to a $ref
+			continue
+		}
+
+		switch name {
+		case "dive":
+			// the remaining rules apply to the elements
+			return required
+		case "email":
+			schema.Format = "email"
+		case "url", "uri":
+			schema.Format = "uri"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		case "alphanum":
+			schema.Pattern = "^[a-zA-Z0-9]+$"
+		case "numeric":
+			schema.Pattern = "^[0-9]+$"
+		case "oneof":
+			schema.Enum = strings.Fields(param)
+		case "len":
+			setBound(schema, param, true)
+			setBound(schema, param, false)
+		case "min", "gte":
+			setBound(schema, param, true)
+		case "max", "lte":
+			setBound(schema, param, false)
+		case "gt":
+			setBound(schema, param, true)
+			schema.ExclusiveMinimum = schema.Minimum != nil
+		case "lt":
+			setBound(schema, param, false)
+			schema.ExclusiveMaximum = schema.Maximum != nil
+		}
+	}
+
+	return required
+}
+
+// setBound applies a min or max rule, whose meaning depends on the schema type
+func setBound(schema *Schema, param string, lower bool) {
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	switch schema.Type {
+	case "string":
+		if lower {
+			schema.MinLength = integer(int(value))
+		} else {
+			schema.MaxLength = integer(int(value))
+		}
+	case "array":
+		if lower {
+			schema.MinItems = integer(int(value))
+		} else {
+			schema.MaxItems = integer(int(value))
+		}
+	case "integer", "number":
+		if lower {
+			schema.Minimum = float(value)
+		} else {
+			schema.Maximum = float(value)
+		}
+	}
+}
+
+func integer(v int) *int {
+	return &v
+}
+
+func float(v float64) *float64 {
+	return &v
+}
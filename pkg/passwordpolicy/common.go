@@ -0,0 +1,34 @@
+package passwordpolicy
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+// commonPasswordsList is a bundled list of frequently used and leaked passwords, one per line.
+// It is checked offline so no password ever leaves the process.
+//
+//go:embed common_passwords.txt
+var commonPasswordsList string
+
+var (
+	commonOnce      sync.Once
+	commonPasswords map[string]struct{}
+)
+
+// IsCommon reports whether password is in the bundled common password list, ignoring case
+func IsCommon(password string) bool {
+	commonOnce.Do(func() {
+		lines := strings.Split(commonPasswordsList, "\n")
+		commonPasswords = make(map[string]struct{}, len(lines))
+		for _, line := range lines {
+			if line = strings.TrimSpace(line); line != "" {
+				commonPasswords[strings.ToLower(line)] = struct{}{}
+			}
+		}
+	})
+
+	_, found := commonPasswords[strings.ToLower(password)]
+	return found
+}
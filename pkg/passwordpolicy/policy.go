@@ -0,0 +1,107 @@
+package passwordpolicy
+
+import (
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/response"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minPersonalLength skips personal values too short to be a meaningful match
+const minPersonalLength = 3
+
+// Policy describes what a new password must satisfy
+type Policy struct {
+	MinLength int
+
+	// MaxLength bounds the input, bcrypt only uses the first 72 bytes
+	MaxLength int
+
+	// Character classes the password must contain
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+
+	// RejectCommon rejects passwords found in the bundled common password list
+	RejectCommon bool
+}
+
+// Check returns one ValidationError per broken rule for field, nil when password is acceptable.
+// personal holds values such as the user's email and name that must not appear in the password,
+// for an email the part before the @ is checked as well.
+func (p Policy) Check(field, password string, personal ...string) []response.ValidationError {
+	var violations []response.ValidationError
+	violate := func(message string) {
+		violations = append(violations, response.ValidationError{Field: field, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		violate("Minimum length is " + strconv.Itoa(p.MinLength))
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		violate("Maximum length is " + strconv.Itoa(p.MaxLength) + " bytes")
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		violate("Must contain an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		violate("Must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		violate("Must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violate("Must contain a symbol")
+	}
+
+	if containsPersonal(password, personal) {
+		violate("Must not contain your email or name")
+	}
+
+	if p.RejectCommon && IsCommon(password) {
+		violate("This password is too common")
+	}
+
+	return violations
+}
+
+// containsPersonal reports whether password contains any of the personal values, ignoring case
+func containsPersonal(password string, personal []string) bool {
+	lowered := strings.ToLower(password)
+
+	for _, value := range personal {
+		candidates := []string{value}
+		if local, _, ok := strings.Cut(value, "@"); ok {
+			candidates = append(candidates, local)
+		}
+
+		for _, candidate := range candidates {
+			candidate = strings.ToLower(strings.TrimSpace(candidate))
+			if utf8.RuneCountInString(candidate) < minPersonalLength {
+				continue
+			}
+			if strings.Contains(lowered, candidate) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
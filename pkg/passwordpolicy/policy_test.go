@@ -0,0 +1,77 @@
+package passwordpolicy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	lengths := Policy{MinLength: 8, MaxLength: 72}
+	classes := Policy{RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		password string
+		personal []string
+		want     []string
+	}{
+		{name: "acceptable", policy: lengths, password: "correct horse battery"},
+		{name: "too short", policy: lengths, password: "short", want: []string{"Minimum length is 8"}},
+		{name: "min length counts runes", policy: lengths, password: "pässwörd"},
+		{name: "too long", policy: lengths, password: strings.Repeat("a", 73), want: []string{"Maximum length is 72 bytes"}},
+		{name: "max length counts bytes", policy: lengths, password: strings.Repeat("ä", 37), want: []string{"Maximum length is 72 bytes"}},
+		{name: "exactly max length", policy: lengths, password: strings.Repeat("a", 72)},
+		{name: "all classes", policy: classes, password: "Tr0ub4dor&3"},
+		{
+			name:     "missing classes",
+			policy:   classes,
+			password: "lowercase",
+			want:     []string{"Must contain an uppercase letter", "Must contain a digit", "Must contain a symbol"},
+		},
+		{name: "space counts as symbol", policy: Policy{RequireSymbol: true}, password: "two words"},
+		{name: "contains email", policy: lengths, password: "xjane@example.comx", personal: []string{"jane@example.com"}, want: []string{"Must not contain your email or name"}},
+		{name: "contains email local part", policy: lengths, password: "iamJaneDoe99", personal: []string{"janedoe@example.com"}, want: []string{"Must not contain your email or name"}},
+		{name: "contains name ignoring case", policy: lengths, password: "hello-ALICE-2024", personal: []string{"Alice"}, want: []string{"Must not contain your email or name"}},
+		{name: "short personal values are ignored", policy: lengths, password: "joyful morning", personal: []string{"jo@example.com", "Jo"}},
+		{name: "common password", policy: Policy{RejectCommon: true}, password: "password", want: []string{"This password is too common"}},
+		{name: "common password ignoring case", policy: Policy{RejectCommon: true}, password: "PassWord", want: []string{"This password is too common"}},
+		{name: "common check disabled", policy: Policy{}, password: "password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := tt.policy.Check("Password", tt.password, tt.personal...)
+
+			var got []string
+			for _, v := range violations {
+				if v.Field != "Password" {
+					t.Errorf("violation field = %q, want Password", v.Field)
+				}
+				got = append(got, v.Message)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsCommon(t *testing.T) {
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"123456", true},
+		{"qwerty", true},
+		{"", false},
+		{"  ", false},
+		{"g7#Lm2$vQ9!x", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsCommon(tt.password); got != tt.want {
+			t.Errorf("IsCommon(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Result describes the outcome of a rate limit check
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// NormalizeKey folds an identifier such as an email the same way for every
+// limiter and lockout key, so " Jane@Example.com" and "jane@example.com" share one counter
+func NormalizeKey(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// Limiter is a fixed window rate limiter
+type Limiter struct {
+	store  Store
+	limit  int
+	window time.Duration
+}
+
+// NewLimiter allows limit requests per key in every window
+func NewLimiter(store Store, limit int, window time.Duration) *Limiter {
+	return &Limiter{store: store, limit: limit, window: window}
+}
+
+// Allow counts a request for key and reports whether it is within the limit
+func (l *Limiter) Allow(ctx context.Context, key string) (Result, error) {
+	count, ttl, err := l.store.Incr(ctx, key, l.window)
+	if err != nil {
+		return Result{Allowed: true, Limit: l.limit, Remaining: l.limit}, err
+	}
+
+	result := Result{
+		Allowed:   count <= int64(l.limit),
+		Limit:     l.limit,
+		Remaining: l.limit - int(count),
+	}
+	if result.Remaining < 0 {
+		result.Remaining = 0
+	}
+	if !result.Allowed {
+		result.RetryAfter = ttl
+	}
+
+	return result, nil
+}
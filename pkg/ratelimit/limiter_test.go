@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// failingStore fails every call, like an unreachable Redis
+type failingStore struct{}
+
+var errStoreDown = errors.New("store down")
+
+func (failingStore) Incr(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	return 0, 0, errStoreDown
+}
+
+func (failingStore) Set(ctx context.Context, key string, ttl time.Duration) error {
+	return errStoreDown
+}
+
+func (failingStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return 0, errStoreDown
+}
+
+func (failingStore) Delete(ctx context.Context, keys ...string) error {
+	return errStoreDown
+}
+
+func TestLimiterAllow(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewLimiter(NewMemoryStore(), 3, time.Minute)
+
+	tests := []struct {
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		{true, 2},
+		{true, 1},
+		{true, 0},
+		{false, 0},
+		{false, 0},
+	}
+
+	for i, tt := range tests {
+		result, err := limiter.Allow(ctx, "ip:1.2.3.4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Allowed != tt.wantAllowed || result.Remaining != tt.wantRemaining || result.Limit != 3 {
+			t.Errorf("request %d: Allow() = %+v, want allowed %v with %d remaining", i+1, result, tt.wantAllowed, tt.wantRemaining)
+		}
+		if result.Allowed && result.RetryAfter != 0 {
+			t.Errorf("request %d: RetryAfter = %v on an allowed request", i+1, result.RetryAfter)
+		}
+		if !result.Allowed && (result.RetryAfter <= 0 || result.RetryAfter > time.Minute) {
+			t.Errorf("request %d: RetryAfter = %v, want the rest of the window", i+1, result.RetryAfter)
+		}
+	}
+
+	// keys are counted separately
+	if result, err := limiter.Allow(ctx, "ip:5.6.7.8"); err != nil || !result.Allowed {
+		t.Errorf("Allow() for another key = %+v, %v, want allowed", result, err)
+	}
+}
+
+func TestLimiterWindowResets(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewLimiter(NewMemoryStore(), 1, 10*time.Millisecond)
+
+	if result, _ := limiter.Allow(ctx, "key"); !result.Allowed {
+		t.Fatal("first request was not allowed")
+	}
+	if result, _ := limiter.Allow(ctx, "key"); result.Allowed {
+		t.Fatal("second request in the window was allowed")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if result, _ := limiter.Allow(ctx, "key"); !result.Allowed {
+		t.Error("request in the next window was not allowed")
+	}
+}
+
+func TestLimiterStoreError(t *testing.T) {
+	// the error is returned, the middleware decides whether to fail open or closed
+	result, err := NewLimiter(failingStore{}, 3, time.Minute).Allow(context.Background(), "key")
+	if !errors.Is(err, errStoreDown) {
+		t.Errorf("Allow() error = %v, want the store error", err)
+	}
+	if result.Limit != 3 {
+		t.Errorf("Allow() limit = %d, want 3", result.Limit)
+	}
+}
+
+func TestNormalizeKey(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"jane@example.com", "jane@example.com"},
+		{"Jane@Example.COM", "jane@example.com"},
+		{"  jane@example.com\t", "jane@example.com"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeKey(tt.value); got != tt.want {
+			t.Errorf("NormalizeKey(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
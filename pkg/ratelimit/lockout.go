@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Lockout locks a key after repeated failures. Once threshold failures are
+// reached within the failure window, every further failure doubles the lock
+// duration, starting at baseLock and capped at maxLock.
+type Lockout struct {
+	store     Store
+	threshold int
+	baseLock  time.Duration
+	maxLock   time.Duration
+	window    time.Duration
+}
+
+// NewLockout creates a Lockout, failures are forgotten after window without new ones
+func NewLockout(store Store, threshold int, baseLock, maxLock, window time.Duration) *Lockout {
+	return &Lockout{
+		store:     store,
+		threshold: threshold,
+		baseLock:  baseLock,
+		maxLock:   maxLock,
+		window:    window,
+	}
+}
+
+// Locked returns how long key is still locked, zero when it is not
+func (l *Lockout) Locked(ctx context.Context, key string) (time.Duration, error) {
+	return l.store.TTL(ctx, lockKey(key))
+}
+
+// Fail records a failure and returns the lock duration it caused, if any
+func (l *Lockout) Fail(ctx context.Context, key string) (time.Duration, error) {
+	failures, _, err := l.store.Incr(ctx, failKey(key), l.window)
+	if err != nil {
+		return 0, err
+	}
+
+	if failures < int64(l.threshold) {
+		return 0, nil
+	}
+
+	lock := l.baseLock
+	for i := int64(l.threshold); i < failures && lock < l.maxLock; i++ {
+		lock *= 2
+	}
+	if lock > l.maxLock {
+		lock = l.maxLock
+	}
+
+	return lock, l.store.Set(ctx, lockKey(key), lock)
+}
+
+// Reset clears failures and any lock, called after a successful attempt
+func (l *Lockout) Reset(ctx context.Context, key string) error {
+	return l.store.Delete(ctx, failKey(key), lockKey(key))
+}
+
+func failKey(key string) string {
+	return "lockout:fail:" + key
+}
+
+func lockKey(key string) string {
+	return "lockout:lock:" + key
+}
@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLockoutBackoff(t *testing.T) {
+	ctx := context.Background()
+	lockout := NewLockout(NewMemoryStore(), 3, time.Minute, 10*time.Minute, time.Hour)
+
+	// failures below the threshold do not lock, from there on each one doubles
+	// the lock until it reaches the cap
+	want := []time.Duration{
+		0,
+		0,
+		time.Minute,
+		2 * time.Minute,
+		4 * time.Minute,
+		8 * time.Minute,
+		10 * time.Minute,
+		10 * time.Minute,
+	}
+
+	for i, wantLock := range want {
+		lock, err := lockout.Fail(ctx, "jane@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lock != wantLock {
+			t.Errorf("failure %d: Fail() = %v, want %v", i+1, lock, wantLock)
+		}
+
+		locked, err := lockout.Locked(ctx, "jane@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wantLock == 0 && locked != 0 {
+			t.Errorf("failure %d: Locked() = %v, want unlocked", i+1, locked)
+		}
+		if wantLock != 0 && (locked <= wantLock-time.Second || locked > wantLock) {
+			t.Errorf("failure %d: Locked() = %v, want about %v", i+1, locked, wantLock)
+		}
+	}
+}
+
+func TestLockoutCapBelowBase(t *testing.T) {
+	lockout := NewLockout(NewMemoryStore(), 1, time.Minute, 30*time.Second, time.Hour)
+
+	lock, err := lockout.Fail(context.Background(), "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock != 30*time.Second {
+		t.Errorf("Fail() = %v, want the 30s cap", lock)
+	}
+}
+
+func TestLockoutReset(t *testing.T) {
+	ctx := context.Background()
+	lockout := NewLockout(NewMemoryStore(), 2, time.Minute, 10*time.Minute, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		if _, err := lockout.Fail(ctx, "key"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := lockout.Reset(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+
+	if locked, err := lockout.Locked(ctx, "key"); err != nil || locked != 0 {
+		t.Errorf("Locked() after Reset = %v, %v, want unlocked", locked, err)
+	}
+	// the failure count starts over
+	if lock, err := lockout.Fail(ctx, "key"); err != nil || lock != 0 {
+		t.Errorf("Fail() after Reset = %v, %v, want no lock", lock, err)
+	}
+}
+
+func TestLockoutFailuresExpire(t *testing.T) {
+	ctx := context.Background()
+	lockout := NewLockout(NewMemoryStore(), 2, time.Minute, 10*time.Minute, 10*time.Millisecond)
+
+	if _, err := lockout.Fail(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if lock, err := lockout.Fail(ctx, "key"); err != nil || lock != 0 {
+		t.Errorf("Fail() after the failure window = %v, %v, want no lock", lock, err)
+	}
+}
+
+func TestLockoutStoreError(t *testing.T) {
+	lockout := NewLockout(failingStore{}, 1, time.Minute, 10*time.Minute, time.Hour)
+
+	if _, err := lockout.Fail(context.Background(), "key"); !errors.Is(err, errStoreDown) {
+		t.Errorf("Fail() error = %v, want the store error", err)
+	}
+	if _, err := lockout.Locked(context.Background(), "key"); !errors.Is(err, errStoreDown) {
+		t.Errorf("Locked() error = %v, want the store error", err)
+	}
+}
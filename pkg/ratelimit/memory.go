@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryCounter struct {
+	count     int64
+	expiresAt time.Time
+}
+
+// MemoryStore is an in-process Store, limits are per instance
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]*memoryCounter
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[string]*memoryCounter)}
+}
+
+func (s *MemoryStore) Incr(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	counter, ok := s.counters[key]
+	if !ok || now.After(counter.expiresAt) {
+		counter = &memoryCounter{expiresAt: now.Add(window)}
+		s.counters[key] = counter
+		s.sweep(now)
+	}
+	counter.count++
+
+	return counter.count, counter.expiresAt.Sub(now), nil
+}
+
+func (s *MemoryStore) Set(ctx context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counters[key] = &memoryCounter{count: 1, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counter, ok := s.counters[key]
+	if !ok {
+		return 0, nil
+	}
+	ttl := time.Until(counter.expiresAt)
+	if ttl <= 0 {
+		delete(s.counters, key)
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.counters, key)
+	}
+	return nil
+}
+
+// sweep drops expired counters so the map does not grow with every client ever seen
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, counter := range s.counters {
+		if now.After(counter.expiresAt) {
+			delete(s.counters, key)
+		}
+	}
+}
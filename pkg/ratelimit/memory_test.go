@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreIncr(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	for want := int64(1); want <= 3; want++ {
+		count, ttl, err := store.Incr(ctx, "key", time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != want {
+			t.Errorf("Incr() count = %d, want %d", count, want)
+		}
+		if ttl <= 0 || ttl > time.Minute {
+			t.Errorf("Incr() ttl = %v, want within the window", ttl)
+		}
+	}
+
+	// the window is fixed, later increments do not extend it
+	store.counters["key"].expiresAt = time.Now().Add(time.Second)
+	if _, ttl, _ := store.Incr(ctx, "key", time.Minute); ttl > time.Second {
+		t.Errorf("Incr() ttl = %v, want the remaining window", ttl)
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	if _, _, err := store.Incr(ctx, "old", 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(ctx, "lock", 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if count, _, _ := store.Incr(ctx, "old", time.Minute); count != 1 {
+		t.Errorf("Incr() after the window = %d, want a new count of 1", count)
+	}
+	if ttl, err := store.TTL(ctx, "lock"); err != nil || ttl != 0 {
+		t.Errorf("TTL() after expiry = %v, %v, want 0", ttl, err)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if _, _, err := store.Incr(ctx, key, 10*time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// a new key sweeps the expired ones
+	if _, _, err := store.Incr(ctx, "d", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.counters) != 1 {
+		t.Errorf("counters = %d after sweep, want 1", len(store.counters))
+	}
+}
+
+func TestMemoryStoreSetTTLDelete(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	if ttl, err := store.TTL(ctx, "missing"); err != nil || ttl != 0 {
+		t.Errorf("TTL() of a missing key = %v, %v, want 0", ttl, err)
+	}
+
+	if err := store.Set(ctx, "lock", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, err := store.TTL(ctx, "lock"); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("TTL() = %v, %v, want within a minute", ttl, err)
+	}
+
+	if err := store.Delete(ctx, "lock", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, _ := store.TTL(ctx, "lock"); ttl != 0 {
+		t.Errorf("TTL() after Delete = %v, want 0", ttl)
+	}
+}
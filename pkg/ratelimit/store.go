@@ -0,0 +1,18 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps expiring counters for the limiter and lockout
+type Store interface {
+	// Incr increments key, starting a window of the given length when the
+	// key is new, and returns the count and the time left in the window
+	Incr(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error)
+	// Set marks key as present for ttl
+	Set(ctx context.Context, key string, ttl time.Duration) error
+	// TTL returns how long key is still present, zero when absent
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -12,9 +12,10 @@ type BaseResponse struct {
 }
 
 type ErrorData struct {
-	Code    int               `json:"code"`
-	Message string            `json:"message"`
-	Details []ValidationError `json:"details,omitempty"`
+	Code      int               `json:"code"`
+	ErrorCode string            `json:"error_code,omitempty"`
+	Message   string            `json:"message"`
+	Details   []ValidationError `json:"details,omitempty"`
 }
 
 type ValidationError struct {
@@ -30,13 +31,17 @@ type PaginatedResponse struct {
 	Pagination Pagination  `json:"pagination"`
 }
 
+// Pagination describes a page of results. Offset pagination fills Page,
+// Total and TotalPages, cursor pagination fills the cursors and skips the count.
 type Pagination struct {
-	Page       int  `json:"page"`
-	Size       int  `json:"size"`
-	Total      int  `json:"total"`
-	TotalPages int  `json:"total_pages"`
-	HasNext    bool `json:"has_next"`
-	HasPrev    bool `json:"has_prev"`
+	Page       int    `json:"page,omitempty"`
+	Size       int    `json:"size"`
+	Total      *int   `json:"total,omitempty"`
+	TotalPages *int   `json:"total_pages,omitempty"`
+	HasNext    bool   `json:"has_next"`
+	HasPrev    bool   `json:"has_prev"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
 // Success response helper
@@ -63,8 +68,9 @@ func BadRequest(c *fiber.Ctx, message string) error {
 		Success: false,
 		Message: message,
 		Error: &ErrorData{
-			Code:    fiber.StatusBadRequest,
-			Message: message,
+			Code:      fiber.StatusBadRequest,
+			ErrorCode: "BAD_REQUEST",
+			Message:   message,
 		},
 	})
 }
@@ -74,9 +80,24 @@ func ValidationFailed(c *fiber.Ctx, message string, details []ValidationError) e
 		Success: false,
 		Message: message,
 		Error: &ErrorData{
-			Code:    fiber.StatusBadRequest,
-			Message: message,
-			Details: details,
+			Code:      fiber.StatusBadRequest,
+			ErrorCode: "VALIDATION_FAILED",
+			Message:   message,
+			Details:   details,
+		},
+	})
+}
+
+// Error response helper for an arbitrary status and machine-readable error code
+func Error(c *fiber.Ctx, status int, errorCode, message string, details []ValidationError) error {
+	return c.Status(status).JSON(BaseResponse{
+		Success: false,
+		Message: message,
+		Error: &ErrorData{
+			Code:      status,
+			ErrorCode: errorCode,
+			Message:   message,
+			Details:   details,
 		},
 	})
 }
@@ -99,8 +120,35 @@ func Unauthorized(c *fiber.Ctx, message string) error {
 		Success: false,
 		Message: message,
 		Error: &ErrorData{
-			Code:    fiber.StatusUnauthorized,
-			Message: message,
+			Code:      fiber.StatusUnauthorized,
+			ErrorCode: "UNAUTHORIZED",
+			Message:   message,
+		},
+	})
+}
+
+// Forbidden response helper
+func Forbidden(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusForbidden).JSON(BaseResponse{
+		Success: false,
+		Message: message,
+		Error: &ErrorData{
+			Code:      fiber.StatusForbidden,
+			ErrorCode: "FORBIDDEN",
+			Message:   message,
+		},
+	})
+}
+
+// TooManyRequests response helper
+func TooManyRequests(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusTooManyRequests).JSON(BaseResponse{
+		Success: false,
+		Message: message,
+		Error: &ErrorData{
+			Code:      fiber.StatusTooManyRequests,
+			ErrorCode: "RATE_LIMITED",
+			Message:   message,
 		},
 	})
 }
@@ -111,8 +159,9 @@ func InternalError(c *fiber.Ctx, message string) error {
 		Success: false,
 		Message: message,
 		Error: &ErrorData{
-			Code:    fiber.StatusInternalServerError,
-			Message: message,
+			Code:      fiber.StatusInternalServerError,
+			ErrorCode: "INTERNAL_ERROR",
+			Message:   message,
 		},
 	})
 }
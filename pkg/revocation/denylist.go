@@ -0,0 +1,59 @@
+package revocation
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+const keyPrefix = "revoked:"
+
+// Denylist rejects access tokens before they expire, either one token by its
+// jti or every token of a user issued before a point in time. Entries only
+// live as long as the tokens they deny could still be valid.
+type Denylist struct {
+	store Store
+
+	// tokenTTL is the access token lifetime, the longest a user revocation matters
+	tokenTTL time.Duration
+}
+
+// NewDenylist create new Denylist instance
+func NewDenylist(store Store, tokenTTL time.Duration) *Denylist {
+	return &Denylist{store: store, tokenTTL: tokenTTL}
+}
+
+// RevokeToken denies the token with jti until it expires
+func (d *Denylist) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return d.store.Set(ctx, keyPrefix+"jti:"+jti, 1, ttl)
+}
+
+// RevokeUser denies every token of the user issued before now. Tokens issued
+// afterwards, such as those of a new login, are accepted.
+func (d *Denylist) RevokeUser(ctx context.Context, userID uint) error {
+	return d.store.Set(ctx, userKey(userID), time.Now().UnixMilli(), d.tokenTTL)
+}
+
+// IsRevoked reports whether a token was revoked by its jti or by a later user revocation
+func (d *Denylist) IsRevoked(ctx context.Context, jti string, userID uint, issuedAt time.Time) (bool, error) {
+	if jti != "" {
+		_, revoked, err := d.store.Get(ctx, keyPrefix+"jti:"+jti)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	revokedAt, revoked, err := d.store.Get(ctx, userKey(userID))
+	if err != nil || !revoked {
+		return false, err
+	}
+	return issuedAt.UnixMilli() < revokedAt, nil
+}
+
+func userKey(userID uint) string {
+	return keyPrefix + "user:" + strconv.FormatUint(uint64(userID), 10)
+}
@@ -0,0 +1,131 @@
+package revocation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// failingStore fails every call, like an unreachable Redis
+type failingStore struct{}
+
+var errStoreDown = errors.New("store down")
+
+func (failingStore) Set(ctx context.Context, key string, value int64, ttl time.Duration) error {
+	return errStoreDown
+}
+
+func (failingStore) Get(ctx context.Context, key string) (int64, bool, error) {
+	return 0, false, errStoreDown
+}
+
+func TestDenylistRevokeToken(t *testing.T) {
+	ctx := context.Background()
+	denylist := NewDenylist(NewMemoryStore(), 15*time.Minute)
+
+	if err := denylist.RevokeToken(ctx, "jti-1", time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		jti  string
+		want bool
+	}{
+		{"revoked token", "jti-1", true},
+		{"other token", "jti-2", false},
+		{"token without jti", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revoked, err := denylist.IsRevoked(ctx, tt.jti, 7, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if revoked != tt.want {
+				t.Errorf("IsRevoked() = %v, want %v", revoked, tt.want)
+			}
+		})
+	}
+}
+
+func TestDenylistRevokeTokenAlreadyExpired(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	denylist := NewDenylist(store, 15*time.Minute)
+
+	// an expired token is rejected anyway, there is nothing to store
+	if err := denylist.RevokeToken(ctx, "jti-1", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.entries) != 0 {
+		t.Errorf("entries = %d, want none for an expired token", len(store.entries))
+	}
+}
+
+func TestDenylistRevokeUser(t *testing.T) {
+	ctx := context.Background()
+	denylist := NewDenylist(NewMemoryStore(), 15*time.Minute)
+
+	before := time.Now().Add(-time.Second)
+	if err := denylist.RevokeUser(ctx, 7); err != nil {
+		t.Fatal(err)
+	}
+	after := time.Now().Add(time.Second)
+
+	tests := []struct {
+		name     string
+		userID   uint
+		issuedAt time.Time
+		want     bool
+	}{
+		{"token issued before the revocation", 7, before, true},
+		{"token issued after the revocation", 7, after, false},
+		{"token of another user", 8, before, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revoked, err := denylist.IsRevoked(ctx, "jti", tt.userID, tt.issuedAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if revoked != tt.want {
+				t.Errorf("IsRevoked() = %v, want %v", revoked, tt.want)
+			}
+		})
+	}
+}
+
+func TestDenylistUserRevocationExpires(t *testing.T) {
+	ctx := context.Background()
+	denylist := NewDenylist(NewMemoryStore(), 10*time.Millisecond)
+
+	issuedAt := time.Now().Add(-time.Second)
+	if err := denylist.RevokeUser(ctx, 7); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// by now every token issued before the revocation has expired on its own
+	if revoked, err := denylist.IsRevoked(ctx, "jti", 7, issuedAt); err != nil || revoked {
+		t.Errorf("IsRevoked() after the token lifetime = %v, %v, want false", revoked, err)
+	}
+}
+
+func TestDenylistStoreError(t *testing.T) {
+	ctx := context.Background()
+	denylist := NewDenylist(failingStore{}, 15*time.Minute)
+
+	if _, err := denylist.IsRevoked(ctx, "jti", 7, time.Now()); !errors.Is(err, errStoreDown) {
+		t.Errorf("IsRevoked() error = %v, want the store error", err)
+	}
+	if _, err := denylist.IsRevoked(ctx, "", 7, time.Now()); !errors.Is(err, errStoreDown) {
+		t.Errorf("IsRevoked() without jti error = %v, want the store error", err)
+	}
+	if err := denylist.RevokeUser(ctx, 7); !errors.Is(err, errStoreDown) {
+		t.Errorf("RevokeUser() error = %v, want the store error", err)
+	}
+}
@@ -0,0 +1,39 @@
+package revocation
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	if _, ok, err := store.Get(ctx, "missing"); err != nil || ok {
+		t.Errorf("Get() of a missing key = %v, %v, want absent", ok, err)
+	}
+
+	if err := store.Set(ctx, "key", 42, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok, err := store.Get(ctx, "key"); err != nil || !ok || value != 42 {
+		t.Errorf("Get() = %d, %v, %v, want 42", value, ok, err)
+	}
+
+	if err := store.Set(ctx, "short", 1, 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok, _ := store.Get(ctx, "short"); ok {
+		t.Error("Get() returned an expired entry")
+	}
+
+	// the next Set sweeps expired entries
+	if err := store.Set(ctx, "other", 1, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.entries["short"]; ok {
+		t.Error("expired entry was not swept")
+	}
+}
@@ -0,0 +1,14 @@
+package revocation
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps expiring integer values for the Denylist
+type Store interface {
+	// Set stores value under key for ttl
+	Set(ctx context.Context, key string, value int64, ttl time.Duration) error
+	// Get returns the value of key, false when it is absent or expired
+	Get(ctx context.Context, key string) (int64, bool, error)
+}
@@ -0,0 +1,87 @@
+package secretbox
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	box := New("a secret of at least thirty-two bytes")
+
+	for _, plaintext := range []string{"", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", "ünïcödé"} {
+		sealed, err := box.Seal(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sealed == plaintext {
+			t.Errorf("Seal(%q) returned the plaintext", plaintext)
+		}
+
+		opened, err := box.Open(sealed)
+		if err != nil || opened != plaintext {
+			t.Errorf("Open(Seal(%q)) = %q, %v", plaintext, opened, err)
+		}
+	}
+}
+
+func TestSealUsesFreshNonce(t *testing.T) {
+	box := New("a secret of at least thirty-two bytes")
+
+	first, err := box.Seal("same value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := box.Seal("same value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Error("sealing the same value twice gave the same result")
+	}
+}
+
+func TestOpenRejectsTampering(t *testing.T) {
+	box := New("a secret of at least thirty-two bytes")
+	sealed, err := box.Seal("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flip := func(i int) string {
+		data, err := base64.RawStdEncoding.DecodeString(sealed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i < 0 {
+			i += len(data)
+		}
+		data[i] ^= 0x01
+		return base64.RawStdEncoding.EncodeToString(data)
+	}
+	otherKey, err := New("another secret of at least thirty-two bytes").Seal("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		sealed string
+	}{
+		{"flipped nonce", flip(0)},
+		{"flipped ciphertext", flip(box.aead.NonceSize())},
+		{"flipped tag", flip(-1)},
+		{"truncated", sealed[:len(sealed)-4]},
+		{"shorter than nonce", sealed[:8]},
+		{"not base64", "!!!"},
+		{"empty", ""},
+		{"sealed with another key", otherKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if opened, err := box.Open(tt.sealed); err != ErrInvalid {
+				t.Errorf("Open() = %q, %v, want ErrInvalid", opened, err)
+			}
+		})
+	}
+}
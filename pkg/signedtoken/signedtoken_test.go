@@ -0,0 +1,112 @@
+package signedtoken
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type testClaims struct {
+	UserID uint   `json:"uid"`
+	Email  string `json:"email"`
+}
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	signer := New("a secret of at least thirty-two bytes", "email-verification")
+
+	token, err := signer.Sign(testClaims{UserID: 7, Email: "jane@example.com"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var claims testClaims
+	if err := signer.Verify(token, &claims); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if claims != (testClaims{UserID: 7, Email: "jane@example.com"}) {
+		t.Errorf("Verify() claims = %+v", claims)
+	}
+}
+
+func TestVerifyRejectsExpired(t *testing.T) {
+	signer := New("a secret of at least thirty-two bytes", "email-verification")
+
+	tests := []struct {
+		name string
+		ttl  time.Duration
+	}{
+		{"expired", -time.Minute},
+		{"expiring now", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := signer.Sign(testClaims{UserID: 7}, tt.ttl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := signer.Verify(token, &testClaims{}); err != ErrExpired {
+				t.Errorf("Verify() error = %v, want ErrExpired", err)
+			}
+		})
+	}
+}
+
+func TestVerifySeparatesPurposesAndSecrets(t *testing.T) {
+	const secret = "a secret of at least thirty-two bytes"
+	token, err := New(secret, "email-verification").Sign(testClaims{UserID: 7}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		signer *Signer
+	}{
+		{"other purpose", New(secret, "two-factor-challenge")},
+		{"other secret", New("another secret of at least thirty-two bytes", "email-verification")},
+		{"purpose moved into secret", New(secret+"email-verification", "")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.signer.Verify(token, &testClaims{}); err != ErrInvalid {
+				t.Errorf("Verify() error = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	signer := New("a secret of at least thirty-two bytes", "email-verification")
+	token, err := signer.Sign(testClaims{UserID: 7}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, signature, _ := strings.Cut(token, ".")
+
+	forged, err := New("a secret of at least thirty-two bytes", "email-verification").Sign(testClaims{UserID: 8}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"no signature", payload},
+		{"empty signature", payload + "."},
+		{"swapped payload", forgedPayload + "." + signature},
+		{"truncated signature", payload + "." + signature[:len(signature)-2]},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := signer.Verify(tt.token, &testClaims{}); err != ErrInvalid {
+				t.Errorf("Verify() error = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 seed of the RFC 6238 test vectors, "12345678901234567890" in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B lists 8 digit codes, 6 digit codes are their last 6 digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := Code(rfc6238Secret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code(%d) error = %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeAcceptsLowercaseSecret(t *testing.T) {
+	got, err := Code(strings.ToLower(rfc6238Secret), Step(time.Unix(59, 0)))
+	if err != nil || got != "287082" {
+		t.Errorf("Code() = %s, %v, want 287082", got, err)
+	}
+}
+
+func TestCodeRejectsInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err != ErrInvalidSecret {
+		t.Errorf("Code() error = %v, want ErrInvalidSecret", err)
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := Step(now)
+
+	codeAt := func(step int64) string {
+		code, err := Code(rfc6238Secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", codeAt(current), 1, current, true},
+		{"previous step within skew", codeAt(current - 1), 1, current - 1, true},
+		{"next step within skew", codeAt(current + 1), 1, current + 1, true},
+		{"two steps back beyond skew", codeAt(current - 2), 1, 0, false},
+		{"two steps ahead beyond skew", codeAt(current + 2), 1, 0, false},
+		{"previous step without skew", codeAt(current - 1), 0, 0, false},
+		{"wrong code", "000000", 1, 0, false},
+		{"too short", codeAt(current)[:5], 1, 0, false},
+		{"too long", codeAt(current) + "0", 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(rfc6238Secret, tt.code, now, tt.skew)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("Validate() = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestValidateReturnsStepForReplayCheck(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, err := Code(rfc6238Secret, Step(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same code is still valid later in its window, callers reject it by the repeated step
+	first, ok := Validate(rfc6238Secret, code, now, 1)
+	if !ok {
+		t.Fatal("Validate() rejected the current code")
+	}
+	second, ok := Validate(rfc6238Secret, code, now.Add(Period), 1)
+	if !ok || second != first {
+		t.Errorf("Validate() one step later = %d, %v, want %d, true", second, ok, first)
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("len(GenerateSecret()) = %d, want 32 base32 characters for 20 bytes", len(secret))
+	}
+	if _, err := Code(secret, 1); err != nil {
+		t.Errorf("Code() with a generated secret error = %v", err)
+	}
+
+	other, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == secret {
+		t.Error("two generated secrets are equal")
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("My App", "jane@example.com", rfc6238Secret)
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Scheme != "otpauth" || parsed.Host != "totp" || parsed.Path != "/My App:jane@example.com" {
+		t.Errorf("URI() = %s, want otpauth://totp/My%%20App:jane@example.com", uri)
+	}
+	if strings.Contains(uri, "+") {
+		t.Errorf("URI() = %s, spaces must be encoded as %%20", uri)
+	}
+
+	query := parsed.Query()
+	for key, want := range map[string]string{"secret": rfc6238Secret, "issuer": "My App", "algorithm": "SHA1", "digits": "6", "period": "30"} {
+		if got := query.Get(key); got != want {
+			t.Errorf("URI() %s = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/apperror"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cursor marks a position in a keyset paginated list. Values holds the sort
+// column values of the boundary row, in the same order as the sort. Without
+// values the cursor starts at the end of the list it reads towards, the last
+// page for Before and the first page otherwise.
+type Cursor struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+	Before bool          `json:"b,omitempty"`
+}
+
+// CursorPage is the result of a keyset paginated query
+type CursorPage struct {
+	NextCursor string
+	PrevCursor string
+}
+
+// ErrInvalidCursor is returned for cursors that are malformed or were issued for another sort
+var ErrInvalidCursor = apperror.Validation("INVALID_CURSOR", "Invalid cursor")
+
+// EncodeCursor returns the opaque token handed to clients
+func EncodeCursor(cursor Cursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// SortKey identifies a sort, e.g. "-created_at,title,id"
+func SortKey(sorts []SortField) string {
+	keys := make([]string, len(sorts))
+	for i, sort := range sorts {
+		if sort.Desc {
+			keys[i] = "-" + sort.Field
+		} else {
+			keys[i] = sort.Field
+		}
+	}
+	return strings.Join(keys, ",")
+}
+
+// decodeCursor parses an opaque token and converts its values back to the
+// sort field types so they can be bound as query parameters
+func decodeCursor(token string, sorts []SortField, spec QuerySpec) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor.Wrap(err)
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, ErrInvalidCursor.Wrap(err)
+	}
+
+	if cursor.Sort != SortKey(sorts) || (len(cursor.Values) != 0 && len(cursor.Values) != len(sorts)) {
+		return nil, ErrInvalidCursor
+	}
+
+	// an edge cursor has no values to convert
+	if len(cursor.Values) == 0 {
+		cursor.Values = nil
+		return &cursor, nil
+	}
+
+	for i, sort := range sorts {
+		fieldType := FieldNumber
+		if field, ok := spec.Fields[sort.Field]; ok {
+			fieldType = field.Type
+		}
+
+		value, err := cursorValue(fieldType, cursor.Values[i])
+		if err != nil {
+			return nil, ErrInvalidCursor.Wrap(err)
+		}
+		cursor.Values[i] = value
+	}
+
+	return &cursor, nil
+}
+
+// cursorValue converts a JSON decoded value to the field type
+func cursorValue(fieldType FieldType, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case float64:
+		if fieldType == FieldNumber {
+			return int64(v), nil
+		}
+	case string:
+		switch fieldType {
+		case FieldTime:
+			return time.Parse(time.RFC3339Nano, v)
+		case FieldNumber:
+			return strconv.ParseInt(v, 10, 64)
+		default:
+			return v, nil
+		}
+	}
+	return nil, ErrInvalidCursor
+}
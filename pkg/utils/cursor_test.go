@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	sorts := []SortField{
+		{Field: "created_at", Column: "created_at", Desc: true},
+		{Field: "title", Column: "title"},
+		{Field: "id", Column: "id"},
+	}
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+
+	tests := []struct {
+		name  string
+		token string
+		want  *Cursor
+	}{
+		{
+			name:  "values are converted to the field types",
+			token: EncodeCursor(Cursor{Sort: "-created_at,title,id", Values: []interface{}{createdAt, "Dune", 42}}),
+			want:  &Cursor{Sort: "-created_at,title,id", Values: []interface{}{createdAt, "Dune", int64(42)}},
+		},
+		{
+			name:  "before is kept",
+			token: EncodeCursor(Cursor{Sort: "-created_at,title,id", Values: []interface{}{createdAt, "Dune", 42}, Before: true}),
+			want:  &Cursor{Sort: "-created_at,title,id", Values: []interface{}{createdAt, "Dune", int64(42)}, Before: true},
+		},
+		{
+			name:  "edge cursor without values",
+			token: EncodeCursor(Cursor{Sort: "-created_at,title,id", Before: true}),
+			want:  &Cursor{Sort: "-created_at,title,id", Before: true},
+		},
+		{
+			name:  "edge cursor with an empty value list",
+			token: EncodeCursor(Cursor{Sort: "-created_at,title,id", Values: []interface{}{}}),
+			want:  &Cursor{Sort: "-created_at,title,id"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.token, sorts, testQuerySpec)
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeCursor() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	sorts := []SortField{{Field: "created_at", Column: "created_at", Desc: true}, {Field: "id", Column: "id"}}
+	raw := func(json string) string { return base64.RawURLEncoding.EncodeToString([]byte(json)) }
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"not base64", "!!!"},
+		{"not json", raw("nope")},
+		{"other sort", EncodeCursor(Cursor{Sort: "created_at,id", Values: []interface{}{"2024-01-02T03:04:05Z", 1}})},
+		{"too few values", EncodeCursor(Cursor{Sort: "-created_at,id", Values: []interface{}{"2024-01-02T03:04:05Z"}})},
+		{"too many values", EncodeCursor(Cursor{Sort: "-created_at,id", Values: []interface{}{"2024-01-02T03:04:05Z", 1, 2}})},
+		{"malformed time", EncodeCursor(Cursor{Sort: "-created_at,id", Values: []interface{}{"yesterday", 1}})},
+		{"number for a time", EncodeCursor(Cursor{Sort: "-created_at,id", Values: []interface{}{1, 1}})},
+		{"null value", raw(`{"s":"-created_at,id","v":["2024-01-02T03:04:05Z",null]}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.token, sorts, testQuerySpec); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeCursor() error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestNewPaginationParamsCursor(t *testing.T) {
+	token := EncodeCursor(Cursor{Sort: "title,id", Values: []interface{}{"Dune", 42}})
+
+	params, err := NewPaginationParams(map[string]string{"sort": "title", "cursor": token}, testQuerySpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !params.Keyset {
+		t.Error("a cursor did not switch to keyset pagination")
+	}
+	want := &Cursor{Sort: "title,id", Values: []interface{}{"Dune", int64(42)}}
+	if !reflect.DeepEqual(params.Cursor, want) {
+		t.Errorf("NewPaginationParams() cursor = %#v, want %#v", params.Cursor, want)
+	}
+
+	// the id tiebreaker is part of the sort the cursor was issued for
+	if _, err := NewPaginationParams(map[string]string{"sort": "-title", "cursor": token}, testQuerySpec); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("cursor for another sort error = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/response"
 	"math"
 )
@@ -12,6 +15,14 @@ type PaginationParams struct {
 	Sort   string `query:"sort"`
 	Order  string `query:"order" validate:"oneof=asc desc"`
 	Search string `query:"search"`
+
+	// Sorts and Filters are validated against a QuerySpec by NewPaginationParams
+	Sorts   []SortField
+	Filters []Filter
+
+	// Keyset switches to cursor pagination, Cursor is nil on the first page
+	Keyset bool
+	Cursor *Cursor
 }
 
 // GetOffset menghitung offset untuk database query
@@ -33,18 +44,58 @@ func (p *PaginationParams) GetDefaults() {
 	if p.Order == "" {
 		p.Order = "asc"
 	}
+	if len(p.Sorts) == 0 {
+		p.Sorts = []SortField{{Field: "id", Column: "id", Desc: p.Order == "desc"}}
+	}
+
+	// id as the last sort column keeps the order total, which stable
+	// offsets and keyset cursors both rely on
+	hasID := false
+	for _, sort := range p.Sorts {
+		if sort.Column == "id" {
+			hasID = true
+		}
+	}
+	if !hasID {
+		p.Sorts = append(p.Sorts, SortField{Field: "id", Column: "id"})
+	}
+}
+
+// CacheKey returns a stable digest of everything that affects the result set
+func (p *PaginationParams) CacheKey() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "page=%d|size=%d|search=%s|sort=%s|keyset=%t", p.Page, p.Size, p.Search, SortKey(p.Sorts), p.Keyset)
+	for _, filter := range p.Filters {
+		fmt.Fprintf(h, "|%s:%s:%v", filter.Field, filter.Op, filter.Value)
+	}
+	if p.Cursor != nil {
+		fmt.Fprintf(h, "|cursor=%s", EncodeCursor(*p.Cursor))
+	}
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 // CalculatePagination helper untuk convert ke response.Pagination
 func CalculatePagination(page, size int, total int64) *response.Pagination {
 	totalPages := int(math.Ceil(float64(total) / float64(size)))
+	totalInt := int(total) // Convert int64 to int
 
 	return &response.Pagination{
 		Page:       page,
 		Size:       size,
-		Total:      int(total), // Convert int64 to int
-		TotalPages: totalPages,
+		Total:      &totalInt,
+		TotalPages: &totalPages,
 		HasNext:    page < totalPages,
 		HasPrev:    page > 1,
 	}
 }
+
+// CalculateCursorPagination helper untuk convert keyset page ke response.Pagination
+func CalculateCursorPagination(size int, page *CursorPage) *response.Pagination {
+	return &response.Pagination{
+		Size:       size,
+		HasNext:    page.NextCursor != "",
+		HasPrev:    page.PrevCursor != "",
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	}
+}
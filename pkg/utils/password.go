@@ -1,13 +1,53 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+// PasswordHasher hashes passwords with one algorithm
+type PasswordHasher interface {
+	// Hash returns a self-describing encoded hash of password
+	Hash(password string) (string, error)
+
+	// Verify reports whether password matches hash, the parameters are read from hash
+	Verify(password, hash string) (bool, error)
+
+	// Recognizes reports whether hash was produced by this algorithm
+	Recognizes(hash string) bool
+
+	// NeedsRehash reports whether hash, produced by this algorithm, uses other parameters
+	NeedsRehash(hash string) bool
+}
+
+var (
+	// passwordHasher makes new hashes, see SetPasswordHasher
+	passwordHasher PasswordHasher = NewArgon2idHasher(DefaultArgon2idParams)
+
+	// knownHashers verify stored hashes whatever hasher is currently selected
+	knownHashers = []PasswordHasher{&Argon2idHasher{}, &BcryptHasher{}}
+)
+
+// SetPasswordHasher changes the hasher used by HashPassword. Hashes made by the
+// other algorithm keep verifying, NeedsRehash reports them.
+func SetPasswordHasher(hasher PasswordHasher) {
+	passwordHasher = hasher
+}
 
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytes), err
+	return passwordHasher.Hash(password)
 }
 
+// CheckPasswordHash picks the algorithm from the format of hash
 func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	for _, hasher := range knownHashers {
+		if hasher.Recognizes(hash) {
+			ok, err := hasher.Verify(password, hash)
+			return err == nil && ok
+		}
+	}
+	return false
+}
+
+// NeedsRehash reports whether hash was made with another algorithm or other parameters than the current hasher
+func NeedsRehash(hash string) bool {
+	if !passwordHasher.Recognizes(hash) {
+		return true
+	}
+	return passwordHasher.NeedsRehash(hash)
 }
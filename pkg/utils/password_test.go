@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testArgon2idParams keep the tests fast, the format is the same as with the defaults
+var testArgon2idParams = Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+// useHasher selects hasher for the duration of the test
+func useHasher(t *testing.T, hasher PasswordHasher) {
+	t.Helper()
+	previous := passwordHasher
+	SetPasswordHasher(hasher)
+	t.Cleanup(func() { SetPasswordHasher(previous) })
+}
+
+func newTestBcryptHasher(t *testing.T, cost int) *BcryptHasher {
+	t.Helper()
+	hasher, err := NewBcryptHasher(cost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hasher
+}
+
+func TestArgon2idHasherRoundTrip(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2idParams)
+
+	hash, err := hasher.Hash("s3cret password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("Hash() = %q, want PHC string with the hasher parameters", hash)
+	}
+
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		t.Fatalf("decodeArgon2id() error = %v", err)
+	}
+	if params != testArgon2idParams {
+		t.Errorf("decoded params = %+v, want %+v", params, testArgon2idParams)
+	}
+	if len(salt) != 16 || len(key) != 32 {
+		t.Errorf("decoded salt and key lengths = %d, %d, want 16, 32", len(salt), len(key))
+	}
+
+	for _, tt := range []struct {
+		password string
+		want     bool
+	}{
+		{"s3cret password", true},
+		{"s3cret passwore", false},
+		{"", false},
+	} {
+		ok, err := hasher.Verify(tt.password, hash)
+		if err != nil || ok != tt.want {
+			t.Errorf("Verify(%q) = %v, %v, want %v", tt.password, ok, err, tt.want)
+		}
+	}
+
+	other, err := hasher.Hash("s3cret password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == hash {
+		t.Error("two hashes of the same password are equal, salt is not random")
+	}
+}
+
+func TestArgon2idHasherVerifiesReferenceHash(t *testing.T) {
+	// parameters in the hash win over the hasher's own
+	const hash = "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+
+	ok, err := NewArgon2idHasher(testArgon2idParams).Verify("password", hash)
+	if err != nil || !ok {
+		t.Errorf("Verify() = %v, %v, want true", ok, err)
+	}
+}
+
+func TestArgon2idHasherRejectsMalformedHash(t *testing.T) {
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{"empty", ""},
+		{"bcrypt", "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"},
+		{"argon2i", "$argon2i$v=19$m=1024,t=1,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"},
+		{"missing part", "$argon2id$v=19$m=1024,t=1,p=1$c29tZXNhbHQ"},
+		{"extra part", "$argon2id$v=19$m=1024,t=1,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc$x"},
+		{"old version", "$argon2id$v=16$m=1024,t=1,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"},
+		{"bad params", "$argon2id$v=19$m=x,t=1,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"},
+		{"zero iterations", "$argon2id$v=19$m=1024,t=0,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"},
+		{"zero parallelism", "$argon2id$v=19$m=1024,t=1,p=0$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"},
+		{"bad salt", "$argon2id$v=19$m=1024,t=1,p=1$!!!$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"},
+		{"bad key", "$argon2id$v=19$m=1024,t=1,p=1$c29tZXNhbHQ$!!!"},
+		{"empty key", "$argon2id$v=19$m=1024,t=1,p=1$c29tZXNhbHQ$"},
+	}
+
+	hasher := NewArgon2idHasher(testArgon2idParams)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := hasher.Verify("password", tt.hash)
+			if ok || !errors.Is(err, errInvalidArgon2idHash) {
+				t.Errorf("Verify() = %v, %v, want false, errInvalidArgon2idHash", ok, err)
+			}
+			if hasher.NeedsRehash(tt.hash) {
+				t.Error("NeedsRehash() = true for a malformed hash")
+			}
+		})
+	}
+}
+
+func TestArgon2idHasherNeedsRehash(t *testing.T) {
+	hash, err := NewArgon2idHasher(testArgon2idParams).Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed := func(change func(*Argon2idParams)) Argon2idParams {
+		params := testArgon2idParams
+		change(&params)
+		return params
+	}
+
+	tests := []struct {
+		name   string
+		params Argon2idParams
+		want   bool
+	}{
+		{"same params", testArgon2idParams, false},
+		{"memory", changed(func(p *Argon2idParams) { p.Memory = 2048 }), true},
+		{"iterations", changed(func(p *Argon2idParams) { p.Iterations = 2 }), true},
+		{"parallelism", changed(func(p *Argon2idParams) { p.Parallelism = 2 }), true},
+		{"salt length", changed(func(p *Argon2idParams) { p.SaltLength = 32 }), true},
+		{"key length", changed(func(p *Argon2idParams) { p.KeyLength = 64 }), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewArgon2idHasher(tt.params).NeedsRehash(hash); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPasswordHashAcrossAlgorithms(t *testing.T) {
+	argon2idHash, err := NewArgon2idHasher(testArgon2idParams).Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := newTestBcryptHasher(t, 4).Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the selected hasher does not matter for verification
+	useHasher(t, newTestBcryptHasher(t, 4))
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		want     bool
+	}{
+		{"argon2id match", "password", argon2idHash, true},
+		{"argon2id mismatch", "Password", argon2idHash, false},
+		{"bcrypt match", "password", bcryptHash, true},
+		{"bcrypt mismatch", "Password", bcryptHash, false},
+		{"unknown format", "password", "password", false},
+		{"empty hash", "password", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckPasswordHash(tt.password, tt.hash); got != tt.want {
+				t.Errorf("CheckPasswordHash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsRehashAcrossAlgorithms(t *testing.T) {
+	argon2idHash, err := NewArgon2idHasher(testArgon2idParams).Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := newTestBcryptHasher(t, 4).Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		current PasswordHasher
+		hash    string
+		want    bool
+	}{
+		{"argon2id current", NewArgon2idHasher(testArgon2idParams), argon2idHash, false},
+		{"argon2id with other params", NewArgon2idHasher(DefaultArgon2idParams), argon2idHash, true},
+		{"bcrypt upgraded to argon2id", NewArgon2idHasher(testArgon2idParams), bcryptHash, true},
+		{"bcrypt current", newTestBcryptHasher(t, 4), bcryptHash, false},
+		{"bcrypt with other cost", newTestBcryptHasher(t, 5), bcryptHash, true},
+		{"argon2id downgraded to bcrypt", newTestBcryptHasher(t, 4), argon2idHash, true},
+		{"unknown format", NewArgon2idHasher(testArgon2idParams), "password", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useHasher(t, tt.current)
+			if got := NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewBcryptHasherRejectsInvalidCost(t *testing.T) {
+	for _, cost := range []int{0, 3, 32} {
+		if _, err := NewBcryptHasher(cost); err == nil {
+			t.Errorf("NewBcryptHasher(%d) error = nil, want error", cost)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/apperror"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/response"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType decides how filter values are parsed
+type FieldType int
+
+const (
+	FieldString FieldType = iota
+	FieldNumber
+	FieldTime
+)
+
+// FilterOp is a comparison operator accepted in filters like author=eq:Tolkien
+type FilterOp string
+
+const (
+	OpEq   FilterOp = "eq"
+	OpNe   FilterOp = "ne"
+	OpGt   FilterOp = "gt"
+	OpGte  FilterOp = "gte"
+	OpLt   FilterOp = "lt"
+	OpLte  FilterOp = "lte"
+	OpLike FilterOp = "like"
+	OpIn   FilterOp = "in"
+)
+
+// SQL returns the SQL operator for the filter op
+func (op FilterOp) SQL() string {
+	switch op {
+	case OpNe:
+		return "<>"
+	case OpGt:
+		return ">"
+	case OpGte:
+		return ">="
+	case OpLt:
+		return "<"
+	case OpLte:
+		return "<="
+	case OpLike:
+		return "ILIKE"
+	case OpIn:
+		return "IN"
+	default:
+		return "="
+	}
+}
+
+// QueryField whitelists a query field and maps it to a database column
+type QueryField struct {
+	Column     string
+	Type       FieldType
+	Sortable   bool
+	Filterable bool
+}
+
+// QuerySpec is the per-resource whitelist for sorting and filtering
+type QuerySpec struct {
+	Fields map[string]QueryField
+}
+
+// SortField is a validated ORDER BY column
+type SortField struct {
+	Field  string
+	Column string
+	Desc   bool
+}
+
+// Filter is a validated WHERE condition, Value is typed by the field type
+type Filter struct {
+	Field  string
+	Column string
+	Op     FilterOp
+	Value  interface{}
+}
+
+// reservedQueryKeys are the query parameters that are never treated as filters
+var reservedQueryKeys = map[string]bool{
+	"page":       true,
+	"size":       true,
+	"sort":       true,
+	"order":      true,
+	"search":     true,
+	"cursor":     true,
+	"pagination": true,
+}
+
+// allowedOps lists the operators valid for each field type
+var allowedOps = map[FieldType][]FilterOp{
+	FieldString: {OpEq, OpNe, OpLike, OpIn},
+	FieldNumber: {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn},
+	FieldTime:   {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte},
+}
+
+// ErrInvalidQuery is returned when list query parameters fail validation
+var ErrInvalidQuery = apperror.Validation("INVALID_QUERY", "Invalid query parameters")
+
+// NewPaginationParams parses list query parameters against the spec.
+// Sort accepts a comma separated list where a leading "-" means descending,
+// every other non reserved key is a filter of the form field=op:value.
+// pagination=cursor or a cursor token switches to keyset pagination.
+// Unknown fields and operators are rejected with a validation error.
+func NewPaginationParams(query map[string]string, spec QuerySpec) (*PaginationParams, error) {
+	var details []response.ValidationError
+
+	params := &PaginationParams{
+		Sort:   query["sort"],
+		Order:  strings.ToLower(query["order"]),
+		Search: query["search"],
+		Keyset: query["pagination"] == "cursor" || query["cursor"] != "",
+	}
+
+	if mode := query["pagination"]; mode != "" && mode != "cursor" && mode != "offset" {
+		details = append(details, response.ValidationError{Field: "pagination", Message: "Must be one of: offset cursor", Value: mode})
+	}
+
+	if raw, ok := query["page"]; ok {
+		page, err := strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			details = append(details, response.ValidationError{Field: "page", Message: "Must be a positive integer", Value: raw})
+		}
+		params.Page = page
+	}
+
+	if raw, ok := query["size"]; ok {
+		size, err := strconv.Atoi(raw)
+		if err != nil || size < 1 || size > 100 {
+			details = append(details, response.ValidationError{Field: "size", Message: "Must be between 1 and 100", Value: raw})
+		}
+		params.Size = size
+	}
+
+	if params.Order != "" && params.Order != "asc" && params.Order != "desc" {
+		details = append(details, response.ValidationError{Field: "order", Message: "Must be one of: asc desc", Value: params.Order})
+	}
+
+	sorts, sortErrors := parseSorts(params.Sort, params.Order, spec)
+	params.Sorts = sorts
+	details = append(details, sortErrors...)
+
+	// iterate keys in a stable order so errors and SQL are deterministic
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if !reservedQueryKeys[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		filter, err := parseFilter(key, query[key], spec)
+		if err != nil {
+			details = append(details, *err)
+			continue
+		}
+		params.Filters = append(params.Filters, filter)
+	}
+
+	if len(details) > 0 {
+		return nil, ErrInvalidQuery.WithDetails(details)
+	}
+
+	params.GetDefaults()
+
+	// the cursor is only valid for the sort it was issued with
+	if token := query["cursor"]; token != "" {
+		cursor, err := decodeCursor(token, params.Sorts, spec)
+		if err != nil {
+			return nil, err
+		}
+		params.Cursor = cursor
+	}
+
+	return params, nil
+}
+
+// parseSorts parses "-created_at,title", order is the direction for fields without a prefix
+func parseSorts(raw, order string, spec QuerySpec) ([]SortField, []response.ValidationError) {
+	var sorts []SortField
+	var details []response.ValidationError
+
+	if raw == "" {
+		return nil, nil
+	}
+
+	for _, part := range strings.Split(raw, ",") {
+		name := strings.TrimSpace(part)
+		desc := order == "desc"
+		if strings.HasPrefix(name, "-") {
+			name, desc = name[1:], true
+		} else if strings.HasPrefix(name, "+") {
+			name, desc = name[1:], false
+		}
+
+		field, ok := spec.Fields[name]
+		if !ok || !field.Sortable {
+			details = append(details, response.ValidationError{Field: "sort", Message: "Cannot sort by this field", Value: name})
+			continue
+		}
+
+		sorts = append(sorts, SortField{Field: name, Column: field.Column, Desc: desc})
+	}
+
+	return sorts, details
+}
+
+// parseFilter parses a single field=op:value query parameter
+func parseFilter(name, raw string, spec QuerySpec) (Filter, *response.ValidationError) {
+	field, ok := spec.Fields[name]
+	if !ok || !field.Filterable {
+		return Filter{}, &response.ValidationError{Field: name, Message: "Unknown filter field", Value: raw}
+	}
+
+	// a value without an operator is an equality filter
+	op, value := OpEq, raw
+	if idx := strings.Index(raw, ":"); idx > 0 && isOp(FilterOp(raw[:idx])) {
+		op, value = FilterOp(raw[:idx]), raw[idx+1:]
+	}
+
+	if !opAllowed(field.Type, op) {
+		return Filter{}, &response.ValidationError{Field: name, Message: fmt.Sprintf("Operator %q is not supported for this field", op), Value: raw}
+	}
+
+	filter := Filter{Field: name, Column: field.Column, Op: op}
+
+	if op == OpIn {
+		var values []interface{}
+		for _, item := range strings.Split(value, ",") {
+			parsed, err := parseValue(field.Type, strings.TrimSpace(item))
+			if err != nil {
+				return Filter{}, &response.ValidationError{Field: name, Message: err.Error(), Value: raw}
+			}
+			values = append(values, parsed)
+		}
+		filter.Value = values
+		return filter, nil
+	}
+
+	parsed, err := parseValue(field.Type, value)
+	if err != nil {
+		return Filter{}, &response.ValidationError{Field: name, Message: err.Error(), Value: raw}
+	}
+	if op == OpLike {
+		// the value is matched literally, wildcards in it must not widen the match
+		parsed = "%" + likeEscaper.Replace(value) + "%"
+	}
+	filter.Value = parsed
+
+	return filter, nil
+}
+
+// likeEscaper escapes LIKE wildcards with the backslash the ESCAPE clause names
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// parseValue converts a raw filter value to the field type
+func parseValue(fieldType FieldType, raw string) (interface{}, error) {
+	switch fieldType {
+	case FieldNumber:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, errors.New("Must be a number")
+		}
+		return n, nil
+	case FieldTime:
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, nil
+		}
+		t, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return nil, errors.New("Must be a date (YYYY-MM-DD) or RFC3339 timestamp")
+		}
+		return t, nil
+	default:
+		return raw, nil
+	}
+}
+
+func isOp(op FilterOp) bool {
+	switch op {
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpLike, OpIn:
+		return true
+	}
+	return false
+}
+
+func opAllowed(fieldType FieldType, op FilterOp) bool {
+	for _, allowed := range allowedOps[fieldType] {
+		if allowed == op {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"errors"
+	"github.com/DaffaJatmiko/fiber-rest-boilerplate/pkg/apperror"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testQuerySpec = QuerySpec{
+	Fields: map[string]QueryField{
+		"id":         {Column: "id", Type: FieldNumber, Sortable: true, Filterable: true},
+		"title":      {Column: "title", Type: FieldString, Sortable: true, Filterable: true},
+		"user_id":    {Column: "user_id", Type: FieldNumber, Filterable: true},
+		"created_at": {Column: "created_at", Type: FieldTime, Sortable: true, Filterable: true},
+		"password":   {Column: "password", Type: FieldString},
+	},
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		raw  string
+		want Filter
+	}{
+		{"value without operator", "title", "Dune", Filter{Field: "title", Column: "title", Op: OpEq, Value: "Dune"}},
+		{"explicit operator", "title", "ne:Dune", Filter{Field: "title", Column: "title", Op: OpNe, Value: "Dune"}},
+		{"colon that is not an operator", "title", "Dune: Messiah", Filter{Field: "title", Column: "title", Op: OpEq, Value: "Dune: Messiah"}},
+		{"number", "user_id", "gte:3", Filter{Field: "user_id", Column: "user_id", Op: OpGte, Value: int64(3)}},
+		{"date", "created_at", "lt:2024-01-02", Filter{Field: "created_at", Column: "created_at", Op: OpLt, Value: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}},
+		{"like", "title", "like:dune", Filter{Field: "title", Column: "title", Op: OpLike, Value: "%dune%"}},
+		{"like escapes percent", "title", "like:100%", Filter{Field: "title", Column: "title", Op: OpLike, Value: `%100\%%`}},
+		{"like escapes underscore", "title", "like:a_b", Filter{Field: "title", Column: "title", Op: OpLike, Value: `%a\_b%`}},
+		{"like escapes backslash", "title", `like:a\b`, Filter{Field: "title", Column: "title", Op: OpLike, Value: `%a\\b%`}},
+		{"in numbers", "user_id", "in:1, 2,3", Filter{Field: "user_id", Column: "user_id", Op: OpIn, Value: []interface{}{int64(1), int64(2), int64(3)}}},
+		{"in strings", "title", "in:Dune,Emma", Filter{Field: "title", Column: "title", Op: OpIn, Value: []interface{}{"Dune", "Emma"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFilter(tt.key, tt.raw, testQuerySpec)
+			if err != nil {
+				t.Fatalf("parseFilter() error = %+v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFilter() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		raw     string
+		message string
+	}{
+		{"unknown field", "isbn", "123", "Unknown filter field"},
+		{"field that is not filterable", "password", "secret", "Unknown filter field"},
+		{"like on a number", "user_id", "like:3", `Operator "like" is not supported for this field`},
+		{"gt on a string", "title", "gt:Dune", `Operator "gt" is not supported for this field`},
+		{"in on a time", "created_at", "in:2024-01-02", `Operator "in" is not supported for this field`},
+		{"unknown operator on a number", "user_id", "between:1", "Must be a number"},
+		{"not a number", "user_id", "abc", "Must be a number"},
+		{"not a number in a list", "user_id", "in:1,x", "Must be a number"},
+		{"not a date", "created_at", "gte:yesterday", "Must be a date (YYYY-MM-DD) or RFC3339 timestamp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseFilter(tt.key, tt.raw, testQuerySpec)
+			if err == nil {
+				t.Fatal("parseFilter() error = nil")
+			}
+			if err.Field != tt.key || err.Message != tt.message {
+				t.Errorf("parseFilter() error = %s: %s, want %s: %s", err.Field, err.Message, tt.key, tt.message)
+			}
+		})
+	}
+}
+
+func TestParseSorts(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		order string
+		want  []SortField
+	}{
+		{"empty", "", "", nil},
+		{"prefixes", "-created_at,+title", "", []SortField{{Field: "created_at", Column: "created_at", Desc: true}, {Field: "title", Column: "title"}}},
+		{"order applies without prefix", "title, -id", "desc", []SortField{{Field: "title", Column: "title", Desc: true}, {Field: "id", Column: "id", Desc: true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, details := parseSorts(tt.raw, tt.order, testQuerySpec)
+			if len(details) != 0 {
+				t.Fatalf("parseSorts() details = %+v", details)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSorts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPaginationParamsRejectsUnknownFields(t *testing.T) {
+	query := map[string]string{
+		"sort":     "user_id,-password,title",
+		"isbn":     "123",
+		"password": "eq:secret",
+		"title":    "like:dune",
+	}
+
+	_, err := NewPaginationParams(query, testQuerySpec)
+	if !errors.Is(err, ErrInvalidQuery) {
+		t.Fatalf("NewPaginationParams() error = %v, want ErrInvalidQuery", err)
+	}
+
+	appErr, _ := apperror.As(err)
+	var details []string
+	for _, detail := range appErr.Details {
+		details = append(details, detail.Field+"="+detail.Value)
+	}
+	want := []string{"sort=user_id", "sort=password", "isbn=123", "password=eq:secret"}
+	if !reflect.DeepEqual(details, want) {
+		t.Errorf("NewPaginationParams() details = %v, want %v", details, want)
+	}
+}
+
+func TestNewPaginationParamsFilters(t *testing.T) {
+	params, err := NewPaginationParams(map[string]string{"page": "2", "title": "like:dune", "user_id": "in:1,2"}, testQuerySpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Filter{
+		{Field: "title", Column: "title", Op: OpLike, Value: "%dune%"},
+		{Field: "user_id", Column: "user_id", Op: OpIn, Value: []interface{}{int64(1), int64(2)}},
+	}
+	if !reflect.DeepEqual(params.Filters, want) {
+		t.Errorf("NewPaginationParams() filters = %+v, want %+v", params.Filters, want)
+	}
+	if params.Page != 2 {
+		t.Errorf("NewPaginationParams() page = %d, want 2", params.Page)
+	}
+}
@@ -35,6 +35,10 @@ func getErrorMessage(err validator.FieldError) string {
 		return "Minimum length is " + err.Param()
 	case "max":
 		return "Maximum length is " + err.Param()
+	case "len":
+		return "Length must be " + err.Param()
+	case "numeric":
+		return "Must be a number"
 	case "oneof":
 		return "Must be one of: " + err.Param()
 	default: